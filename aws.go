@@ -0,0 +1,371 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// awsConfig holds the settings shared by the AWS providers.
+type awsConfig struct {
+	region       string
+	endpoint     string
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	client       *http.Client
+	interval     time.Duration
+	maxBackoff   time.Duration
+}
+
+// AWSOption configures an AWS provider.
+type AWSOption func(*awsConfig)
+
+// WithAWSRegion sets the region. The default is taken from AWS_REGION or
+// AWS_DEFAULT_REGION.
+func WithAWSRegion(region string) AWSOption {
+	return func(c *awsConfig) {
+		c.region = region
+	}
+}
+
+// WithAWSCredentials sets static credentials. By default they are taken from
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN.
+func WithAWSCredentials(accessKey, secretKey, sessionToken string) AWSOption {
+	return func(c *awsConfig) {
+		c.accessKey = accessKey
+		c.secretKey = secretKey
+		c.sessionToken = sessionToken
+	}
+}
+
+// WithAWSEndpoint overrides the service endpoint, e.g. for a VPC endpoint or
+// a local stand-in such as LocalStack.
+func WithAWSEndpoint(endpoint string) AWSOption {
+	return func(c *awsConfig) {
+		c.endpoint = strings.TrimSuffix(endpoint, "/")
+	}
+}
+
+// WithAWSRefreshInterval sets how often values are loaded again to detect
+// changes. The default is 5 minutes.
+func WithAWSRefreshInterval(d time.Duration) AWSOption {
+	return func(c *awsConfig) {
+		c.interval = d
+	}
+}
+
+// WithAWSClient sets the HTTP client used for requests.
+func WithAWSClient(client *http.Client) AWSOption {
+	return func(c *awsConfig) {
+		c.client = client
+	}
+}
+
+// newAWSConfig returns the settings from the environment and opts.
+func newAWSConfig(opts []AWSOption) awsConfig {
+	c := awsConfig{
+		region:       os.Getenv("AWS_REGION"),
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		client:       &http.Client{Timeout: 30 * time.Second},
+		interval:     5 * time.Minute,
+		maxBackoff:   5 * time.Minute,
+	}
+	if c.region == "" {
+		c.region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// awsError is the error body of the AWS JSON protocol.
+type awsError struct {
+	Type    string `json:"__type"`
+	Message string `json:"message"`
+	// Some services capitalize the field
+	MessageUpper string `json:"Message"`
+}
+
+// call invokes target, e.g. "AmazonSSM.GetParametersByPath", on service using
+// the AWS JSON 1.1 protocol.
+func (c *awsConfig) call(ctx context.Context, service, target string, in, out any) error {
+	if c.region == "" {
+		return errors.New("no AWS region configured")
+	}
+	if c.accessKey == "" || c.secretKey == "" {
+		return errors.New("no AWS credentials configured")
+	}
+	body, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+	endpoint := c.endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.%s.amazonaws.com", service, c.region)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", target)
+	if c.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.sessionToken)
+	}
+	signV4(req, body, c.accessKey, c.secretKey, c.region, service, time.Now())
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call %s: %w", target, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var e awsError
+		_ = json.NewDecoder(resp.Body).Decode(&e)
+		typ := e.Type[strings.LastIndex(e.Type, "#")+1:]
+		return fmt.Errorf("failed to call %s: %s: %s %s", target, resp.Status, typ, e.Message+e.MessageUpper)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", target, err)
+	}
+	return nil
+}
+
+// signV4 signs req with AWS Signature Version 4, covering the host and every
+// header already set on req.
+func signV4(req *http.Request, body []byte, accessKey, secretKey, region, service string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.Join(values, ",")
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	payloadHash := sha256.Sum256(body)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+	key := []byte("AWS4" + secretKey)
+	for _, part := range []string{date, region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// SSMProvider loads a parameter hierarchy from AWS Systems Manager Parameter
+// Store. A parameter "/billing/prod/db/host" under path "/billing/prod"
+// maps to "db.host". SecureString parameters are decrypted and reported as
+// secret. As a Watcher it loads the parameters again every refresh interval.
+type SSMProvider struct {
+	path string
+	aws  awsConfig
+
+	mu      sync.Mutex
+	data    map[string]any
+	secrets []string
+}
+
+// NewSSMProvider returns a provider that loads the parameters under path.
+func NewSSMProvider(path string, opts ...AWSOption) *SSMProvider {
+	return &SSMProvider{path: "/" + strings.Trim(path, "/"), aws: newAWSConfig(opts)}
+}
+
+// String returns the path as "ssm:PATH".
+func (p *SSMProvider) String() string {
+	return "ssm:" + p.path
+}
+
+// Load returns the parameters under the path.
+func (p *SSMProvider) Load(ctx context.Context) (map[string]any, error) {
+	data, _, err := p.fetch(ctx)
+	return data, err
+}
+
+// SecretKeys reports the keys of SecureString parameters.
+func (p *SSMProvider) SecretKeys() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.secrets
+}
+
+// Watch loads the parameters every refresh interval and sends an Event when
+// they change or cannot be loaded.
+func (p *SSMProvider) Watch(ctx context.Context) <-chan Event {
+	return poll(ctx, p.aws.interval, p.aws.maxBackoff, func(ctx context.Context) (bool, error) {
+		_, changed, err := p.fetch(ctx)
+		return changed, err
+	})
+}
+
+// fetch loads every page of parameters and reports whether they changed.
+func (p *SSMProvider) fetch(ctx context.Context) (map[string]any, bool, error) {
+	type parameter struct {
+		Name  string
+		Type  string
+		Value string
+	}
+	data := make(map[string]any)
+	var secrets []string
+	next := ""
+	for {
+		in := map[string]any{"Path": p.path, "Recursive": true, "WithDecryption": true}
+		if next != "" {
+			in["NextToken"] = next
+		}
+		var out struct {
+			Parameters []parameter
+			NextToken  string
+		}
+		if err := p.aws.call(ctx, "ssm", "AmazonSSM.GetParametersByPath", in, &out); err != nil {
+			return nil, false, err
+		}
+		for _, param := range out.Parameters {
+			rel := strings.Trim(strings.TrimPrefix(param.Name, p.path), "/")
+			if rel == "" {
+				continue
+			}
+			keys := strings.Split(strings.ToLower(rel), "/")
+			var value any = param.Value
+			if param.Type == "StringList" {
+				value = strings.Split(param.Value, ",")
+			}
+			setPath(data, keys, value)
+			if param.Type == "SecureString" {
+				secrets = append(secrets, strings.Join(keys, "."))
+			}
+		}
+		if out.NextToken == "" {
+			break
+		}
+		next = out.NextToken
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	changed := p.data == nil || !reflect.DeepEqual(p.data, data)
+	p.data = data
+	p.secrets = secrets
+	return data, changed, nil
+}
+
+// SecretsManagerProvider loads secrets from AWS Secrets Manager. Every value
+// it returns is reported as secret. As a Watcher it loads the secrets again
+// every refresh interval, so rotated secrets trigger a reload.
+type SecretsManagerProvider struct {
+	secrets map[string]string
+	aws     awsConfig
+
+	mu   sync.Mutex
+	data map[string]any
+}
+
+// NewSecretsManagerProvider returns a provider that loads secrets, a map of
+// config keys to secret names or ARNs. A secret holding a JSON object is
+// placed under its key, e.g. {"password": "..."} under "db" as
+// "db.password"; any other secret is the value of the key itself.
+func NewSecretsManagerProvider(secrets map[string]string, opts ...AWSOption) *SecretsManagerProvider {
+	return &SecretsManagerProvider{secrets: secrets, aws: newAWSConfig(opts)}
+}
+
+// String returns "secretsmanager".
+func (p *SecretsManagerProvider) String() string {
+	return "secretsmanager"
+}
+
+// Load returns the secrets.
+func (p *SecretsManagerProvider) Load(ctx context.Context) (map[string]any, error) {
+	data, _, err := p.fetch(ctx)
+	return data, err
+}
+
+// SecretKeys reports the config key of every secret.
+func (p *SecretsManagerProvider) SecretKeys() []string {
+	keys := make([]string, 0, len(p.secrets))
+	for key := range p.secrets {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Watch loads the secrets every refresh interval and sends an Event when they
+// change or cannot be loaded.
+func (p *SecretsManagerProvider) Watch(ctx context.Context) <-chan Event {
+	return poll(ctx, p.aws.interval, p.aws.maxBackoff, func(ctx context.Context) (bool, error) {
+		_, changed, err := p.fetch(ctx)
+		return changed, err
+	})
+}
+
+// fetch loads every secret and reports whether any changed.
+func (p *SecretsManagerProvider) fetch(ctx context.Context) (map[string]any, bool, error) {
+	data := make(map[string]any)
+	for key, id := range p.secrets {
+		var out struct {
+			SecretString string
+		}
+		if err := p.aws.call(ctx, "secretsmanager", "secretsmanager.GetSecretValue", map[string]string{"SecretId": id}, &out); err != nil {
+			return nil, false, err
+		}
+		var value any = out.SecretString
+		var fields map[string]any
+		if json.Unmarshal([]byte(out.SecretString), &fields) == nil {
+			value = fields
+		}
+		setPath(data, strings.Split(strings.ToLower(key), "."), value)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	changed := p.data == nil || !reflect.DeepEqual(p.data, data)
+	p.data = data
+	return data, changed, nil
+}
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/goleak"
+)
+
+// testAWS is a stand-in for the SSM and Secrets Manager JSON APIs. SSM
+// returns one parameter per page to exercise pagination.
+type testAWS struct {
+	mu      sync.Mutex
+	params  []map[string]string
+	secrets map[string]string
+	auth    string
+	token   string
+}
+
+func (s *testAWS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.auth = r.Header.Get("Authorization")
+	s.token = r.Header.Get("X-Amz-Security-Token")
+	var in map[string]any
+	_ = json.NewDecoder(r.Body).Decode(&in)
+	fail := func(typ, msg string) {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"__type": typ, "message": msg})
+	}
+	switch r.Header.Get("X-Amz-Target") {
+	case "AmazonSSM.GetParametersByPath":
+		if in["WithDecryption"] != true || in["Recursive"] != true {
+			fail("ValidationException", "expected decryption and recursion")
+			return
+		}
+		var matched []map[string]string
+		for _, p := range s.params {
+			if strings.HasPrefix(p["Name"], in["Path"].(string)+"/") {
+				matched = append(matched, p)
+			}
+		}
+		token, _ := in["NextToken"].(string)
+		i, _ := strconv.Atoi(token)
+		out := map[string]any{"Parameters": matched[i : i+1]}
+		if i+1 < len(matched) {
+			out["NextToken"] = strconv.Itoa(i + 1)
+		}
+		_ = json.NewEncoder(w).Encode(out)
+	case "secretsmanager.GetSecretValue":
+		secret, ok := s.secrets[in["SecretId"].(string)]
+		if !ok {
+			fail("com.amazonaws.secretsmanager#ResourceNotFoundException", "Secrets Manager can't find the specified secret.")
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"Name": in["SecretId"].(string), "SecretString": secret})
+	default:
+		fail("UnknownOperationException", "")
+	}
+}
+
+// TestSignV4 tests request signing against the get-vanilla case of the AWS
+// Signature Version 4 test suite.
+func TestSignV4(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	assert.NoError(t, err)
+	signV4(req, nil, "AKIDEXAMPLE", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+	assert.Equal(t, "20150830T123600Z", req.Header.Get("X-Amz-Date"))
+	assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, "+
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		req.Header.Get("Authorization"))
+}
+
+// TestSSMProvider tests loading a parameter hierarchy.
+func TestSSMProvider(t *testing.T) {
+	aws := &testAWS{params: []map[string]string{
+		{"Name": "/billing/prod/db/host", "Type": "String", "Value": "db.internal"},
+		{"Name": "/billing/prod/db/password", "Type": "SecureString", "Value": "hunter2"},
+		{"Name": "/billing/prod/hosts", "Type": "StringList", "Value": "a,b"},
+		{"Name": "/billing/production/other", "Type": "String", "Value": "ignored"},
+	}}
+	srv := httptest.NewServer(aws)
+	defer srv.Close()
+
+	p := NewSSMProvider("/billing/prod/", WithAWSEndpoint(srv.URL), WithAWSRegion("eu-west-1"), WithAWSCredentials("AKID", "SECRET", "session"))
+	assert.Equal(t, "ssm:/billing/prod", p.String())
+	data, err := p.Load(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"db":    map[string]any{"host": "db.internal", "password": "hunter2"},
+		"hosts": []string{"a", "b"},
+	}, data)
+	assert.Equal(t, []string{"db.password"}, p.SecretKeys())
+	assert.True(t, strings.HasPrefix(aws.auth, "AWS4-HMAC-SHA256 Credential=AKID/"), aws.auth)
+	assert.Contains(t, aws.auth, "/eu-west-1/ssm/aws4_request, SignedHeaders=content-type;host;x-amz-date;x-amz-security-token;x-amz-target,")
+	assert.Equal(t, "session", aws.token)
+
+	_, err = NewSSMProvider("/billing", WithAWSEndpoint(srv.URL), WithAWSCredentials("AKID", "SECRET", "")).Load(context.Background())
+	assert.ErrorContains(t, err, "no AWS region configured")
+	_, err = NewSSMProvider("/billing", WithAWSEndpoint(srv.URL), WithAWSRegion("eu-west-1"), WithAWSCredentials("", "", "")).Load(context.Background())
+	assert.ErrorContains(t, err, "no AWS credentials configured")
+}
+
+// TestSecretsManagerProvider tests loading named secrets.
+func TestSecretsManagerProvider(t *testing.T) {
+	os.Setenv("AWS_REGION", "us-east-1")
+	os.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "SECRET")
+	defer os.Unsetenv("AWS_REGION")
+	defer os.Unsetenv("AWS_ACCESS_KEY_ID")
+	defer os.Unsetenv("AWS_SECRET_ACCESS_KEY")
+
+	aws := &testAWS{secrets: map[string]string{
+		"prod/billing/db": `{"user": "billing", "password": "hunter2"}`,
+		"prod/api-key":    "abc123",
+	}}
+	srv := httptest.NewServer(aws)
+	defer srv.Close()
+
+	p := NewSecretsManagerProvider(map[string]string{"db": "prod/billing/db", "api.key": "prod/api-key"}, WithAWSEndpoint(srv.URL))
+	data, err := p.Load(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"db":  map[string]any{"user": "billing", "password": "hunter2"},
+		"api": map[string]any{"key": "abc123"},
+	}, data)
+	assert.ElementsMatch(t, []string{"db", "api.key"}, p.SecretKeys())
+	assert.Contains(t, aws.auth, "/us-east-1/secretsmanager/aws4_request")
+
+	_, err = NewSecretsManagerProvider(map[string]string{"db": "missing"}, WithAWSEndpoint(srv.URL)).Load(context.Background())
+	assert.ErrorContains(t, err, "400 Bad Request: ResourceNotFoundException Secrets Manager can't find the specified secret.")
+}
+
+// TestAWSProviderRefresh tests reloads triggered by periodic refresh.
+func TestAWSProviderRefresh(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	aws := &testAWS{params: []map[string]string{
+		{"Name": "/billing/db/password", "Type": "SecureString", "Value": "hunter2"},
+	}}
+	srv := httptest.NewServer(aws)
+	defer srv.Close()
+
+	p := NewSSMProvider("/billing",
+		WithAWSEndpoint(srv.URL),
+		WithAWSRegion("eu-west-1"),
+		WithAWSCredentials("AKID", "SECRET", ""),
+		WithAWSRefreshInterval(20*time.Millisecond),
+	)
+	cfg, err := NewContext(context.Background(), WithProvider(p, 30), WithLogger(slog.New(slog.DiscardHandler)))
+	assert.NoError(t, err)
+	defer cfg.Close()
+
+	events := make(chan ChangeEvent, 10)
+	cfg.OnChange(func(e ChangeEvent) {
+		events <- e
+	})
+	aws.mu.Lock()
+	aws.params[0]["Value"] = "correct-horse"
+	aws.mu.Unlock()
+	select {
+	case e := <-events:
+		assert.Len(t, e.Changes, 1)
+		assert.Equal(t, Redacted, e.Changes[0].New)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for refresh")
+	}
+	assert.Equal(t, "correct-horse", cfg.GetString("db.password"))
+	assert.NoError(t, cfg.Close())
+}
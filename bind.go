@@ -0,0 +1,92 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sync/atomic"
+)
+
+// Validator is implemented by bound types that validate themselves after
+// decoding.
+type Validator interface {
+	Validate() error
+}
+
+// binder keeps a live value in sync with the configuration.
+type binder interface {
+	// prepare decodes and validates the bound subtree of next. It returns a
+	// function that publishes the value, or nil if the subtree is unchanged.
+	prepare(next *Config) (publish func(), err error)
+}
+
+// binding is the binder created by Bind.
+type binding[T any] struct {
+	key   string
+	ptr   *atomic.Pointer[T]
+	raw   interface{}
+	bound bool
+}
+
+// Bind decodes the subtree at key into T and returns a pointer that always
+// holds the latest validated value. Whenever Set or a reload changes the
+// subtree, the new value is decoded and validated before the change is
+// committed; if that fails, the whole change is rejected and the pointer
+// keeps its value. Fields tagged ",required" must be non-zero, and T may
+// implement Validator for additional checks.
+func Bind[T any](c *Config, key string) (*atomic.Pointer[T], error) {
+	r := c.base()
+	b := &binding[T]{key: c.key(key), ptr: new(atomic.Pointer[T])}
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+	r.mu.RLock()
+	publish, err := b.prepare(r)
+	r.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	publish()
+	r.binders = append(r.binders, b)
+	return b.ptr, nil
+}
+
+func (b *binding[T]) prepare(next *Config) (func(), error) {
+	raw := lookup(next.v, b.key)
+	if b.bound && reflect.DeepEqual(raw, b.raw) {
+		return nil, nil
+	}
+	val := new(T)
+	if err := decode(raw, val); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", b.key, err)
+	}
+	if rv := reflect.ValueOf(val).Elem(); rv.Kind() == reflect.Struct {
+		if err := validateRequired(rv, b.key); err != nil {
+			return nil, err
+		}
+	}
+	if v, ok := any(val).(Validator); ok {
+		if err := v.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %w", b.key, err)
+		}
+	}
+	return func() {
+		b.raw = raw
+		b.bound = true
+		b.ptr.Store(val)
+	}, nil
+}
+
+// prepareBinders prepares every binder of c against next and returns the
+// functions that publish the changed values.
+func (c *Config) prepareBinders(next *Config) ([]func(), error) {
+	var publish []func()
+	for _, b := range c.binders {
+		p, err := b.prepare(next)
+		if err != nil {
+			return nil, err
+		}
+		if p != nil {
+			publish = append(publish, p)
+		}
+	}
+	return publish, nil
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type boundDatabase struct {
+	Host    string        `mapstructure:"host,required"`
+	Port    int           `mapstructure:"port"`
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+func (d *boundDatabase) Validate() error {
+	if d.Port < 0 {
+		return errors.New("port must not be negative")
+	}
+	return nil
+}
+
+// TestBind tests that bound values follow changes.
+func TestBind(t *testing.T) {
+	cfg := newTestConfig(t, `
+database:
+  host: db.local
+  port: 5432
+  timeout: 5s
+`)
+	live, err := Bind[boundDatabase](cfg, "database")
+	assert.NoError(t, err)
+	first := live.Load()
+	assert.Equal(t, boundDatabase{Host: "db.local", Port: 5432, Timeout: 5 * time.Second}, *first)
+
+	assert.NoError(t, cfg.Set("database.port", 6432))
+	assert.Equal(t, 6432, live.Load().Port)
+	assert.Equal(t, 5432, first.Port)
+
+	// Unrelated changes keep the same value
+	current := live.Load()
+	assert.NoError(t, cfg.Set("debug", true))
+	assert.Same(t, current, live.Load())
+
+	port, err := Bind[int](cfg.Sub("database"), "port")
+	assert.NoError(t, err)
+	assert.Equal(t, 6432, *port.Load())
+}
+
+// TestBindRejectsInvalidChanges tests that invalid subtrees reject the change.
+func TestBindRejectsInvalidChanges(t *testing.T) {
+	cfg := newTestConfig(t, `
+database:
+  host: db.local
+  port: 5432
+`)
+	live, err := Bind[boundDatabase](cfg, "database")
+	assert.NoError(t, err)
+
+	err = cfg.Set("database.port", -1)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "port must not be negative")
+	assert.Equal(t, 5432, live.Load().Port)
+	assert.Equal(t, 5432, cfg.GetInt("database.port"))
+
+	err = cfg.Set("database.host", "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "required field database.Host is not set")
+
+	err = cfg.Set("database.timeout", "forever")
+	assert.Error(t, err)
+	assert.Equal(t, "db.local", live.Load().Host)
+
+	_, err = Bind[boundDatabase](cfg, "missing")
+	assert.Error(t, err)
+}
+
+// TestBindConcurrentLoad tests reading bound values during changes.
+func TestBindConcurrentLoad(t *testing.T) {
+	cfg := newTestConfig(t, `
+database:
+  host: db.local
+  port: 1
+`)
+	live, err := Bind[boundDatabase](cfg, "database")
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, cfg.Set("database.port", i))
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				assert.Equal(t, "db.local", live.Load().Host)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, cfg.GetInt("database.port"), live.Load().Port)
+}
@@ -0,0 +1,300 @@
+package config
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// CachePolicy selects what CachedProvider does when its provider fails.
+type CachePolicy int
+
+const (
+	// CacheStartFromCache returns the cached data, marked as stale, when the
+	// provider fails. Load fails only if there is no cache yet.
+	CacheStartFromCache CachePolicy = iota
+	// CacheFailFast returns the provider's error. The cache is still
+	// written, but never read.
+	CacheFailFast
+)
+
+// CachedProvider wraps a remote provider and persists the data of every
+// successful Load to a local file. When the provider is unreachable, e.g. at
+// startup, it falls back to the cached data according to its CachePolicy and
+// reports the data as stale. As a Watcher it forwards the events of the
+// wrapped provider and, while stale, retries it until it recovers.
+type CachedProvider struct {
+	p          Provider
+	path       string
+	policy     CachePolicy
+	key        []byte
+	retry      time.Duration
+	maxBackoff time.Duration
+
+	mu      sync.Mutex
+	stale   bool
+	savedAt time.Time
+	secrets []string
+}
+
+// CacheOption configures a CachedProvider.
+type CacheOption func(*CachedProvider)
+
+// WithCachePolicy sets what happens when the provider fails. The default is
+// CacheStartFromCache.
+func WithCachePolicy(policy CachePolicy) CacheOption {
+	return func(p *CachedProvider) {
+		p.policy = policy
+	}
+}
+
+// WithCacheEncryptionKey encrypts the cache file with AES-GCM. key must be
+// 16, 24 or 32 bytes long.
+func WithCacheEncryptionKey(key []byte) CacheOption {
+	return func(p *CachedProvider) {
+		p.key = key
+	}
+}
+
+// WithCacheRetryInterval sets how often the provider is retried while the
+// data is stale. The default is 30 seconds.
+func WithCacheRetryInterval(d time.Duration) CacheOption {
+	return func(p *CachedProvider) {
+		p.retry = d
+	}
+}
+
+// NewCachedProvider returns a provider that loads p and caches its data in
+// the file at path, e.g.
+// NewCachedProvider(NewHTTPProvider(url), "/var/cache/billing/config.json").
+func NewCachedProvider(p Provider, path string, opts ...CacheOption) *CachedProvider {
+	c := &CachedProvider{
+		p:          p,
+		path:       path,
+		retry:      30 * time.Second,
+		maxBackoff: 5 * time.Minute,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// String returns the name of the wrapped provider.
+func (c *CachedProvider) String() string {
+	return providerName(c.p)
+}
+
+// Stale reports whether the data returned by the last Load came from the
+// cache because the provider failed.
+func (c *CachedProvider) Stale() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stale
+}
+
+// CacheAge returns how long ago the cached data returned by the last Load
+// was saved, or zero if the data is not stale.
+func (c *CachedProvider) CacheAge() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.stale {
+		return 0
+	}
+	return time.Since(c.savedAt)
+}
+
+// Load loads the wrapped provider and saves its data to the cache, or falls
+// back to the cache if the provider fails.
+func (c *CachedProvider) Load(ctx context.Context) (map[string]any, error) {
+	data, err := c.fetch(ctx)
+	if err == nil || c.policy == CacheFailFast {
+		return data, err
+	}
+	entry, cacheErr := c.readCache()
+	if cacheErr != nil {
+		return nil, fmt.Errorf("%w (no usable cache: %v)", err, cacheErr)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stale = true
+	c.savedAt = entry.SavedAt
+	c.secrets = entry.Secrets
+	return entry.Data, nil
+}
+
+// SecretKeys reports the secret keys of the wrapped provider, or those saved
+// with the cache while the data is stale.
+func (c *CachedProvider) SecretKeys() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.secrets
+}
+
+// Watch forwards the events of the wrapped provider if it is a Watcher. While
+// the data is stale, it also retries the provider every retry interval and
+// sends an Event once it succeeds.
+func (c *CachedProvider) Watch(ctx context.Context) <-chan Event {
+	var inner <-chan Event
+	if w, ok := c.p.(Watcher); ok {
+		inner = w.Watch(ctx)
+	}
+	retries := poll(ctx, c.retry, c.maxBackoff, func(ctx context.Context) (bool, error) {
+		if !c.Stale() {
+			return false, nil
+		}
+		_, err := c.fetch(ctx)
+		return err == nil, err
+	})
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		for inner != nil || retries != nil {
+			var e Event
+			var ok bool
+			select {
+			case <-ctx.Done():
+				return
+			case e, ok = <-inner:
+				if !ok {
+					inner = nil
+					continue
+				}
+			case e, ok = <-retries:
+				if !ok {
+					retries = nil
+					continue
+				}
+			}
+			if !sendEvent(ctx, events, e) {
+				return
+			}
+		}
+	}()
+	return events
+}
+
+// cacheEntry is the content of a cache file.
+type cacheEntry struct {
+	SavedAt time.Time      `json:"saved_at"`
+	Data    map[string]any `json:"data"`
+	Secrets []string       `json:"secrets,omitempty"`
+}
+
+// fetch loads the wrapped provider and saves its data to the cache. A cache
+// that cannot be written does not fail the load.
+func (c *CachedProvider) fetch(ctx context.Context) (map[string]any, error) {
+	data, err := c.p.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var secrets []string
+	if sp, ok := c.p.(SecretProvider); ok {
+		secrets = sp.SecretKeys()
+	}
+	_ = c.writeCache(cacheEntry{SavedAt: time.Now(), Data: data, Secrets: secrets})
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stale = false
+	c.savedAt = time.Time{}
+	c.secrets = secrets
+	return data, nil
+}
+
+// writeCache atomically replaces the cache file with entry. The file is only
+// readable by its owner.
+func (c *CachedProvider) writeCache(entry cacheEntry) error {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache: %w", err)
+	}
+	if c.key != nil {
+		if b, err = c.seal(b); err != nil {
+			return err
+		}
+	}
+	dir := filepath.Dir(c.path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	f, err := os.CreateTemp(dir, filepath.Base(c.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	if err := os.Rename(f.Name(), c.path); err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	return nil
+}
+
+// readCache reads and decodes the cache file.
+func (c *CachedProvider) readCache() (cacheEntry, error) {
+	var entry cacheEntry
+	b, err := os.ReadFile(c.path)
+	if err != nil {
+		return entry, fmt.Errorf("failed to read cache: %w", err)
+	}
+	if c.key != nil {
+		if b, err = c.open(b); err != nil {
+			return entry, err
+		}
+	}
+	if err := json.Unmarshal(b, &entry); err != nil {
+		return entry, fmt.Errorf("failed to decode cache: %w", err)
+	}
+	return entry, nil
+}
+
+// seal encrypts plaintext, prefixing it with a random nonce.
+func (c *CachedProvider) seal(plaintext []byte) ([]byte, error) {
+	gcm, err := c.gcm()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(plaintext)+gcm.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// open decrypts data sealed by seal.
+func (c *CachedProvider) open(data []byte) ([]byte, error) {
+	gcm, err := c.gcm()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("failed to decrypt cache: too short")
+	}
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt cache: %w", err)
+	}
+	return plaintext, nil
+}
+
+// gcm returns the AES-GCM cipher for the encryption key.
+func (c *CachedProvider) gcm() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(c.key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cache encryption key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
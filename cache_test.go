@@ -0,0 +1,99 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/goleak"
+)
+
+// TestCachedProvider tests saving data and falling back to the cache.
+func TestCachedProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "billing.json")
+	inner := newTestProvider("remote", map[string]any{"environment": "production", "db": map[string]any{"host": "db.internal"}})
+	p := NewCachedProvider(inner, path)
+	assert.Equal(t, "remote", p.String())
+
+	data, err := p.Load(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, inner.data, data)
+	assert.False(t, p.Stale())
+	assert.Zero(t, p.CacheAge())
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	inner.set(nil, errors.New("connection refused"))
+	data, err = p.Load(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"environment": "production", "db": map[string]any{"host": "db.internal"}}, data)
+	assert.True(t, p.Stale())
+	assert.Greater(t, p.CacheAge(), time.Duration(0))
+
+	_, err = NewCachedProvider(inner, path, WithCachePolicy(CacheFailFast)).Load(context.Background())
+	assert.EqualError(t, err, "connection refused")
+	_, err = NewCachedProvider(inner, filepath.Join(t.TempDir(), "missing.json")).Load(context.Background())
+	assert.ErrorContains(t, err, "connection refused (no usable cache: failed to read cache")
+}
+
+// TestCachedProviderEncryption tests encrypting the cache file.
+func TestCachedProviderEncryption(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "billing.cache")
+	key := []byte("0123456789abcdef0123456789abcdef")
+	inner := newTestProvider("remote", map[string]any{"db": map[string]any{"password": "hunter2"}})
+	_, err := NewCachedProvider(inner, path, WithCacheEncryptionKey(key)).Load(context.Background())
+	assert.NoError(t, err)
+	b, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(b), "hunter2")
+
+	inner.set(nil, errors.New("connection refused"))
+	data, err := NewCachedProvider(inner, path, WithCacheEncryptionKey(key)).Load(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"db": map[string]any{"password": "hunter2"}}, data)
+
+	_, err = NewCachedProvider(inner, path, WithCacheEncryptionKey([]byte("fedcba9876543210fedcba9876543210"))).Load(context.Background())
+	assert.ErrorContains(t, err, "failed to decrypt cache")
+	_, err = NewCachedProvider(inner, path).Load(context.Background())
+	assert.ErrorContains(t, err, "failed to decode cache")
+}
+
+// TestCachedProviderRecovery tests starting from the cache and switching to
+// the provider once it recovers.
+func TestCachedProviderRecovery(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	path := filepath.Join(t.TempDir(), "billing.json")
+	inner := newTestProvider("remote", map[string]any{"environment": "staging"})
+	_, err := NewCachedProvider(inner, path).Load(context.Background())
+	assert.NoError(t, err)
+
+	inner.set(nil, errors.New("connection refused"))
+	p := NewCachedProvider(inner, path, WithCacheRetryInterval(20*time.Millisecond))
+	cfg, err := NewContext(context.Background(), WithProvider(p, 10), WithLogger(slog.New(slog.DiscardHandler)))
+	assert.NoError(t, err)
+	defer cfg.Close()
+	assert.Equal(t, "staging", cfg.GetConfigStruct().Environment)
+	assert.Equal(t, "provider:remote", cfg.Source("environment"))
+	assert.True(t, p.Stale())
+
+	events := make(chan ChangeEvent, 10)
+	cfg.OnChange(func(e ChangeEvent) {
+		events <- e
+	})
+	inner.set(map[string]any{"environment": "production"}, nil)
+	select {
+	case <-events:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for recovery")
+	}
+	assert.Equal(t, "production", cfg.GetConfigStruct().Environment)
+	assert.False(t, p.Stale())
+	assert.NoError(t, cfg.Close())
+}
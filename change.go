@@ -0,0 +1,298 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// ErrReadOnly is returned when modifying a view created with Sub.
+var ErrReadOnly = errors.New("config view is read-only")
+
+// ChangeSource identifies what triggered a configuration change.
+type ChangeSource string
+
+const (
+	// ChangeSet is reported for changes made with Set.
+	ChangeSet ChangeSource = "set"
+	// ChangeUnset is reported for changes made with Unset.
+	ChangeUnset ChangeSource = "unset"
+	// ChangeReload is reported for changes made with Reload.
+	ChangeReload ChangeSource = "reload"
+)
+
+// ChangeEvent describes a successful configuration change.
+type ChangeEvent struct {
+	Source ChangeSource
+	// Key is the key passed to Set or Unset; it is empty for reloads.
+	Key string
+	// Changes lists the keys that differ from the previous state.
+	Changes []Change
+}
+
+// Subscription is returned by OnChange. Close stops further notifications.
+type Subscription struct {
+	c      *Config
+	closed atomic.Bool
+	notify func(e ChangeEvent, prev, next *Config)
+}
+
+// pendingChange is a change waiting to be delivered to subscribers.
+type pendingChange struct {
+	event      ChangeEvent
+	prev, next *Config
+}
+
+// override is a value set with Set.
+type override struct {
+	key   string
+	value interface{}
+}
+
+// sourceCache holds the data read from files and providers by build, so
+// that changing overrides does not read the sources again. Reload starts
+// with an empty cache.
+type sourceCache struct {
+	files     map[string][]byte
+	providers []providerResult
+}
+
+// newSourceCache returns an empty sourceCache.
+func newSourceCache() *sourceCache {
+	return &sourceCache{files: make(map[string][]byte)}
+}
+
+// Set sets key to value in the override layer, which takes precedence over all
+// other sources. Overrides are applied in the order they were set, so a later
+// Set wins over an earlier one of the same key, its parent or its children.
+// The overrides are applied to the sources as they were last read, which
+// only Reload does again. The result is validated; on failure the current
+// state is kept and the error is returned.
+func (c *Config) Set(key string, value interface{}) error {
+	if c.root != nil {
+		return ErrReadOnly
+	}
+	key = strings.ToLower(key)
+	err := c.update(context.Background(), ChangeEvent{Source: ChangeSet, Key: key}, func(overrides []override) ([]override, bool) {
+		overrides, _ = removeOverride(overrides, key)
+		return append(overrides, override{key: key, value: value}), true
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set %s: %w", key, err)
+	}
+	return nil
+}
+
+// Unset removes key from the override layer, restoring the value from the
+// underlying sources. Unsetting a key that was never Set is a no-op.
+func (c *Config) Unset(key string) error {
+	if c.root != nil {
+		return ErrReadOnly
+	}
+	key = strings.ToLower(key)
+	err := c.update(context.Background(), ChangeEvent{Source: ChangeUnset, Key: key}, func(overrides []override) ([]override, bool) {
+		return removeOverride(overrides, key)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to unset %s: %w", key, err)
+	}
+	return nil
+}
+
+// removeOverride returns overrides without the one for key and reports
+// whether there was one.
+func removeOverride(overrides []override, key string) ([]override, bool) {
+	for i, o := range overrides {
+		if o.key == key {
+			return append(overrides[:i:i], overrides[i+1:]...), true
+		}
+	}
+	return overrides, false
+}
+
+// isOverridden reports whether key was set with Set.
+func (c *Config) isOverridden(key string) bool {
+	for _, o := range c.overrides {
+		if o.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// OnChange registers fn to be called after every successful change. Callbacks
+// run after the new state is visible, one change at a time, in the order the
+// changes were made and then in registration order. A change is delivered by
+// the goroutine that made it unless another change is being delivered at the
+// time, e.g. from another goroutine or because it was made from within a
+// callback: it is then queued and delivered by the goroutine already
+// delivering, so the call that made it may return before its callbacks have
+// run. Views created with Sub register on their root configuration.
+func (c *Config) OnChange(fn func(ChangeEvent)) *Subscription {
+	return c.base().subscribe(func(e ChangeEvent, _, _ *Config) {
+		fn(e)
+	})
+}
+
+// Close stops delivering notifications to the subscriber. It is safe to call
+// more than once.
+func (s *Subscription) Close() error {
+	s.closed.Store(true)
+	s.c.notifyMu.Lock()
+	defer s.c.notifyMu.Unlock()
+	for i, sub := range s.c.subs {
+		if sub == s {
+			s.c.subs = append(s.c.subs[:i:i], s.c.subs[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// subscribe registers notify on c.
+func (c *Config) subscribe(notify func(e ChangeEvent, prev, next *Config)) *Subscription {
+	c.notifyMu.Lock()
+	defer c.notifyMu.Unlock()
+	s := &Subscription{c: c, notify: notify}
+	if c.isClosed() {
+		s.closed.Store(true)
+		return s
+	}
+	c.subs = append(c.subs, s)
+	return s
+}
+
+// update rebuilds the configuration with the overrides returned by mutate,
+// commits it if it is valid and notifies subscribers. Nothing happens if
+// mutate reports no change; a nil mutate keeps the overrides as they are.
+// Only reloads read the sources again.
+func (c *Config) update(ctx context.Context, e ChangeEvent, mutate func([]override) ([]override, bool)) error {
+	deliver, err := c.apply(ctx, e, mutate)
+	if deliver {
+		c.deliver()
+	}
+	return err
+}
+
+// apply performs the transactional part of update while holding writeMu and
+// reports whether the caller must deliver notifications.
+func (c *Config) apply(ctx context.Context, e ChangeEvent, mutate func([]override) ([]override, bool)) (bool, error) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.isClosed() {
+		return false, ErrClosed
+	}
+	overrides := slices.Clone(c.overrides)
+	if mutate != nil {
+		var changed bool
+		if overrides, changed = mutate(overrides); !changed {
+			return false, nil
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	cache := c.cache
+	if e.Source == ChangeReload {
+		cache = nil
+	}
+	next, err := build(ctx, c.opts, overrides, cache)
+	if err != nil {
+		return false, err
+	}
+	publish, err := c.prepareBinders(next)
+	if err != nil {
+		return false, err
+	}
+	// Abandon the change if the caller gave up while it was being built
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	prev := c.commit(next)
+	for _, p := range publish {
+		p()
+	}
+	e.Changes = Diff(prev, next)
+	return c.enqueue(pendingChange{event: e, prev: prev, next: next}), nil
+}
+
+// commit replaces the state of c with the state of next and returns a
+// detached Config holding the previous state.
+func (c *Config) commit(next *Config) *Config {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	prev := &Config{}
+	c.copyState(prev)
+	next.copyState(c)
+	return prev
+}
+
+// copyState copies the fields produced by build from c to dst.
+func (c *Config) copyState(dst *Config) {
+	dst.v = c.v
+	dst.configStruct = c.configStruct
+	dst.registry = c.registry
+	dst.envPrefix = c.envPrefix
+	dst.logger = c.logger
+	dst.opts = c.opts
+	dst.overrides = c.overrides
+	dst.cache = c.cache
+	dst.providers = c.providers
+	dst.providerSources = c.providerSources
+	dst.providerSecrets = c.providerSecrets
+	dst.sources = c.sources
+	dst.snapshot.Store(c.snapshot.Load())
+}
+
+// enqueue queues a change for delivery and reports whether the caller must
+// deliver the queue.
+func (c *Config) enqueue(p pendingChange) bool {
+	c.notifyMu.Lock()
+	defer c.notifyMu.Unlock()
+	c.pending = append(c.pending, p)
+	if c.delivering {
+		return false
+	}
+	c.delivering = true
+	return true
+}
+
+// deliver notifies subscribers of queued changes in order until the queue is
+// empty.
+func (c *Config) deliver() {
+	c.notifyMu.Lock()
+	for len(c.pending) > 0 {
+		p := c.pending[0]
+		c.pending = c.pending[1:]
+		subs := append([]*Subscription(nil), c.subs...)
+		c.notifyMu.Unlock()
+		for _, s := range subs {
+			if !s.closed.Load() {
+				s.notify(p.event, p.prev, p.next)
+			}
+		}
+		c.notifyMu.Lock()
+	}
+	c.delivering = false
+	c.notifyMu.Unlock()
+}
+
+// applyOverrides applies the override layer in the order it was set and
+// validates the result.
+func (c *Config) applyOverrides() error {
+	if len(c.overrides) == 0 {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, o := range c.overrides {
+		c.v.Set(o.key, o.value)
+	}
+	if err := c.v.Unmarshal(&c.configStruct); err != nil {
+		return fmt.Errorf("failed to unmarshal ConfigStruct: %w", err)
+	}
+	return c.validateRequiredFields()
+}
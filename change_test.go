@@ -0,0 +1,180 @@
+package config
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSetAndUnset tests the override layer.
+func TestSetAndUnset(t *testing.T) {
+	cfg := newTestConfig(t, `
+environment: production
+debug: false
+app:
+  name: file-app
+`)
+	assert.NoError(t, cfg.Set("debug", true))
+	assert.NoError(t, cfg.Set("App.Name", "override-app"))
+	assert.NoError(t, cfg.Set("settings.theme", "dark"))
+	assert.True(t, cfg.GetBool("debug"))
+	assert.True(t, cfg.GetConfigStruct().Debug)
+	assert.Equal(t, "override-app", cfg.GetString("app.name"))
+	assert.Equal(t, map[string]string{"theme": "dark"}, cfg.GetConfigStruct().Settings)
+
+	assert.NoError(t, cfg.Unset("app.name"))
+	assert.Equal(t, "file-app", cfg.GetString("app.name"))
+	assert.True(t, cfg.GetBool("debug"))
+
+	assert.NoError(t, cfg.Unset("debug"))
+	assert.False(t, cfg.GetConfigStruct().Debug)
+	assert.NoError(t, cfg.Unset("never.set"))
+}
+
+// TestSetOverlappingKeys tests that the last Set wins over earlier overrides
+// of a parent or child key.
+func TestSetOverlappingKeys(t *testing.T) {
+	cfg, err := New()
+	assert.NoError(t, err)
+	for i := 0; i < 50; i++ {
+		assert.NoError(t, cfg.Set("app.port", 2))
+		assert.NoError(t, cfg.Set("app", map[string]interface{}{"port": 1, "name": "billing"}))
+		assert.NoError(t, cfg.Set("app.port", 3))
+		assert.Equal(t, 3, cfg.GetInt("app.port"))
+		assert.Equal(t, "billing", cfg.GetString("app.name"))
+
+		assert.NoError(t, cfg.Set("app", map[string]interface{}{"port": 4}))
+		assert.Equal(t, 4, cfg.GetInt("app.port"))
+		assert.Empty(t, cfg.GetString("app.name"))
+		assert.NoError(t, cfg.Unset("app"))
+		assert.NoError(t, cfg.Unset("app.port"))
+	}
+}
+
+// TestSetKeepsSources tests that Set and Unset do not read the sources again.
+func TestSetKeepsSources(t *testing.T) {
+	cfg := newTestConfig(t, "environment: production\n")
+	path := cfg.v.ConfigFileUsed()
+	writeFile(t, path, "environment: staging\n")
+
+	var events []ChangeEvent
+	cfg.OnChange(func(e ChangeEvent) {
+		events = append(events, e)
+	})
+	assert.NoError(t, cfg.Set("debug", true))
+	assert.Equal(t, "production", cfg.GetConfigStruct().Environment)
+	assert.Equal(t, []Change{{Key: "debug", Kind: ChangeAdded, New: true, NewSource: "override"}}, events[0].Changes)
+
+	assert.NoError(t, os.Remove(path))
+	assert.NoError(t, cfg.Unset("debug"))
+	assert.Equal(t, "production", cfg.GetConfigStruct().Environment)
+	assert.Error(t, cfg.Reload(context.Background()))
+
+	writeFile(t, path, "environment: staging\n")
+	assert.NoError(t, cfg.Reload(context.Background()))
+	assert.Equal(t, "staging", cfg.GetConfigStruct().Environment)
+}
+
+// TestSetValidation tests that invalid overrides are rejected.
+func TestSetValidation(t *testing.T) {
+	r := NewRegistry()
+	assert.NoError(t, r.Register(Key[int]{Path: "app.port", Default: 8080}))
+	cfg, err := New(WithRegistry(r))
+	assert.NoError(t, err)
+
+	err = cfg.Set("environment", "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "required field Environment is not set")
+	assert.Equal(t, "development", cfg.GetConfigStruct().Environment)
+
+	err = cfg.Set("app.port", "not-a-port")
+	assert.Error(t, err)
+	assert.Equal(t, 8080, cfg.GetInt("app.port"))
+
+	assert.ErrorIs(t, cfg.Sub("app").Set("port", 9090), ErrReadOnly)
+	assert.ErrorIs(t, cfg.Sub("app").Unset("port"), ErrReadOnly)
+}
+
+// TestOnChange tests change notifications.
+func TestOnChange(t *testing.T) {
+	cfg, err := New()
+	assert.NoError(t, err)
+
+	var events []ChangeEvent
+	var order []int
+	sub := cfg.OnChange(func(e ChangeEvent) {
+		events = append(events, e)
+		order = append(order, 1)
+		assert.Equal(t, e.Source == ChangeSet, cfg.GetBool("debug"))
+	})
+	cfg.Sub("app").OnChange(func(e ChangeEvent) {
+		order = append(order, 2)
+	})
+
+	assert.NoError(t, cfg.Set("debug", true))
+	assert.NoError(t, cfg.Unset("debug"))
+	assert.Error(t, cfg.Set("environment", ""))
+	assert.Len(t, events, 2)
+	assert.Equal(t, ChangeSet, events[0].Source)
+	assert.Equal(t, "debug", events[0].Key)
+	assert.Equal(t, []Change{{Key: "debug", Kind: ChangeAdded, New: true, NewSource: "override"}}, events[0].Changes)
+	assert.Equal(t, ChangeUnset, events[1].Source)
+	assert.Equal(t, []Change{{Key: "debug", Kind: ChangeRemoved, Old: true, OldSource: "override"}}, events[1].Changes)
+	assert.Equal(t, []int{1, 2, 1, 2}, order)
+
+	assert.NoError(t, sub.Close())
+	assert.NoError(t, sub.Close())
+	assert.NoError(t, cfg.Set("debug", true))
+	assert.Len(t, events, 2)
+}
+
+// TestOnChangeReentrant tests changes made from within a callback.
+func TestOnChangeReentrant(t *testing.T) {
+	cfg, err := New()
+	assert.NoError(t, err)
+
+	var keys []string
+	cfg.OnChange(func(e ChangeEvent) {
+		keys = append(keys, e.Key)
+		if e.Key == "a" {
+			assert.NoError(t, cfg.Set("b", 2))
+			assert.Equal(t, []string{"a"}, keys)
+		}
+	})
+	assert.NoError(t, cfg.Set("a", 1))
+	assert.Equal(t, []string{"a", "b"}, keys)
+	assert.Equal(t, 2, cfg.GetInt("b"))
+}
+
+// TestConcurrentSet tests concurrent changes and reads.
+func TestConcurrentSet(t *testing.T) {
+	cfg, err := New()
+	assert.NoError(t, err)
+
+	var mu sync.Mutex
+	count := 0
+	cfg.OnChange(func(ChangeEvent) {
+		mu.Lock()
+		count++
+		mu.Unlock()
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, cfg.Set("counter", i))
+		}(i)
+		go func() {
+			defer wg.Done()
+			_ = cfg.GetInt("counter")
+			_ = cfg.GetConfigStruct()
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 10, count)
+}
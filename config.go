@@ -1,11 +1,18 @@
 package config
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"log/slog"
+	"maps"
+	"os"
 	"path/filepath"
 	"reflect"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/spf13/viper"
 )
@@ -15,13 +22,61 @@ type Config struct {
 	mu           sync.RWMutex
 	v            *viper.Viper
 	configStruct ConfigStruct
+	registry     *Registry
+	envPrefix    string
+	logger       *slog.Logger
+
+	// opts and overrides are the inputs of the current state. They are
+	// re-applied by build whenever the configuration changes. Overrides are
+	// kept in the order they were set. cache holds what the options read, so
+	// that only Reload reads the sources again.
+	opts      []Option
+	overrides []override
+	cache     *sourceCache
+
+	// providers are the sources added with WithProvider. providerSources
+	// records which of them supplied each key, and providerSecrets the keys
+	// they reported as secret.
+	providers       []providerEntry
+	providerSources map[string]string
+	providerSecrets map[string]bool
+
+	// sources records where each key's value comes from.
+	sources map[string]string
+
+	// snapshot is the flattened view published for lock-free reads.
+	snapshot atomic.Pointer[Snapshot]
+
+	// writeMu serializes changes and guards binders; notifyMu guards
+	// subscribers and the queue of pending change notifications.
+	writeMu    sync.Mutex
+	binders    []binder
+	notifyMu   sync.Mutex
+	subs       []*Subscription
+	pending    []pendingChange
+	delivering bool
+
+	// lifeMu guards the lifecycle fields. ctx is cancelled by Close, and wg
+	// tracks the background goroutines that stop when it is done. stopAfter
+	// unregisters the Close hook on the context passed to NewContext.
+	lifeMu    sync.Mutex
+	ctx       context.Context
+	cancel    context.CancelFunc
+	stopAfter func() bool
+	closed    bool
+	wg        sync.WaitGroup
+
+	// root and prefix are set on views created with Sub. Views share the
+	// lock and state of root and qualify every key with prefix.
+	root   *Config
+	prefix string
 }
 
 // ConfigStruct defines configuration fields with default and required tags.
 type ConfigStruct struct {
-	Environment string            `mapstructure:"environment,required" default:"development"`
-	Debug       bool              `mapstructure:"debug" default:"false"`
-	Settings    map[string]string `mapstructure:"settings" default:""`
+	Environment string            `mapstructure:"environment,required" default:"development" desc:"Deployment environment name."`
+	Debug       bool              `mapstructure:"debug" default:"false" desc:"Enables debug behaviour."`
+	Settings    map[string]string `mapstructure:"settings" default:"" desc:"Free-form string settings."`
 }
 
 // Option configures the Config instance and may return an error.
@@ -41,8 +96,16 @@ func WithFilepath(path string) Option {
 		default:
 			return fmt.Errorf("unsupported file format: %s", path)
 		}
+		b, ok := c.cache.files[path]
+		if !ok {
+			var err error
+			if b, err = os.ReadFile(path); err != nil {
+				return fmt.Errorf("failed to read config file %s: %w", path, err)
+			}
+			c.cache.files[path] = b
+		}
 		c.v.SetConfigFile(path)
-		if err := c.v.ReadInConfig(); err != nil {
+		if err := c.v.ReadConfig(bytes.NewReader(b)); err != nil {
 			return fmt.Errorf("failed to read config file %s: %w", path, err)
 		}
 		if err := c.v.Unmarshal(&c.configStruct); err != nil {
@@ -78,6 +141,7 @@ func WithEnv(prefix string) Option {
 	return func(c *Config) error {
 		c.mu.Lock()
 		defer c.mu.Unlock()
+		c.envPrefix = prefix
 		c.v.SetEnvPrefix(strings.ToUpper(prefix))
 		c.v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 		c.v.AutomaticEnv()
@@ -100,12 +164,28 @@ func WithEnv(prefix string) Option {
 
 // New creates a new Config instance.
 func New(opts ...Option) (*Config, error) {
+	return NewContext(context.Background(), opts...)
+}
+
+// build creates a Config by applying struct tag defaults, opts, providers and
+// overrides in order, then validating the result. It is used by New and to
+// rebuild the state whenever the configuration changes. Sources already read
+// into cache are not read again; a nil cache reads every source.
+func build(ctx context.Context, opts []Option, overrides []override, cache *sourceCache) (*Config, error) {
+	if cache == nil {
+		cache = newSourceCache()
+	}
 	v := viper.New()
 	c := &Config{
 		v: v,
 		configStruct: ConfigStruct{
 			Settings: make(map[string]string),
 		},
+		registry:  DefaultRegistry,
+		logger:    slog.Default(),
+		opts:      opts,
+		overrides: overrides,
+		cache:     cache,
 	}
 	// Apply defaults before validating required fields
 	if err := c.applyDefaults(); err != nil {
@@ -119,6 +199,17 @@ func New(opts ...Option) (*Config, error) {
 			return nil, err
 		}
 	}
+	if err := c.applyProviders(ctx); err != nil {
+		return nil, err
+	}
+	if err := c.applyOverrides(); err != nil {
+		return nil, err
+	}
+	if err := c.applyRegistry(); err != nil {
+		return nil, fmt.Errorf("registry validation failed: %w", err)
+	}
+	c.sources = c.resolveSources()
+	c.snapshot.Store(newSnapshot(c.v))
 	return c, nil
 }
 
@@ -157,62 +248,126 @@ func (c *Config) applyDefaults() error {
 
 // validateRequiredFields checks for required fields in ConfigStruct.
 func (c *Config) validateRequiredFields() error {
-	v := reflect.ValueOf(c.configStruct)
+	return validateRequired(reflect.ValueOf(c.configStruct), "")
+}
+
+// validateRequired checks that fields tagged ",required" in the struct v and
+// its nested structs are not zero. Nested field names are qualified by path.
+func validateRequired(v reflect.Value, path string) error {
 	t := v.Type()
 	for i := 0; i < t.NumField(); i++ {
 		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		f := v.Field(i)
+		name := field.Name
+		if path != "" {
+			name = path + "." + field.Name
+		}
 		tag := field.Tag.Get("mapstructure")
-		if strings.Contains(tag, ",required") {
-			f := v.Field(i)
-			if f.IsZero() {
-				return fmt.Errorf("required field %s is not set", field.Name)
+		if strings.Contains(tag, ",required") && f.IsZero() {
+			return fmt.Errorf("required field %s is not set", name)
+		}
+		if f.Kind() == reflect.Struct {
+			if err := validateRequired(f, name); err != nil {
+				return err
 			}
 		}
 	}
 	return nil
 }
 
-// Get retrieves a configuration value by key.
+// Get retrieves a configuration value by key. Maps and slices are returned as
+// copies.
 func (c *Config) Get(key string) interface{} {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	return c.v.Get(key)
+	r := c.base()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return cloneValue(r.v.Get(c.key(key)))
 }
 
 // GetStringWithDefault retrieves a string value with a default.
 func (c *Config) GetStringWithDefault(key, defaultValue string) string {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	if c.v.IsSet(key) {
-		return c.v.GetString(key)
+	r := c.base()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.v.IsSet(c.key(key)) {
+		return r.v.GetString(c.key(key))
 	}
 	return defaultValue
 }
 
 // GetBool retrieves a boolean value.
 func (c *Config) GetBool(key string) bool {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	return c.v.GetBool(key)
+	r := c.base()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.v.GetBool(c.key(key))
 }
 
-// GetStringMapString retrieves a map[string]string.
+// GetStringMapString retrieves a copy of a map[string]string, or an empty map
+// if the key is unset or cannot be converted.
 func (c *Config) GetStringMapString(key string) map[string]string {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	return c.v.GetStringMapString(key)
+	val, err := c.GetStringMapStringE(key)
+	if err != nil {
+		return map[string]string{}
+	}
+	return val
 }
 
-// GetConfigStruct retrieves the ConfigStruct.
+// GetConfigStruct retrieves a deep copy of the ConfigStruct, so callers may
+// modify the result freely. Views created with Sub return the ConfigStruct of
+// the root configuration.
 func (c *Config) GetConfigStruct() ConfigStruct {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	return c.configStruct
+	r := c.base()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.configStruct.clone()
 }
 
-// Unmarshal unmarshals the entire configuration into the target struct.
-func (c *Config) Unmarshal(target interface{}) error {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	return c.v.Unmarshal(target)
+// clone returns a deep copy of s.
+func (s ConfigStruct) clone() ConfigStruct {
+	s.Settings = maps.Clone(s.Settings)
+	return s
+}
+
+// cloneValue returns a deep copy of maps and slices in val so that callers
+// cannot modify the internal state. Other values are returned as is.
+func cloneValue(val interface{}) interface{} {
+	switch t := val.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			m[k] = cloneValue(v)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(t))
+		for i, v := range t {
+			s[i] = cloneValue(v)
+		}
+		return s
+	case map[string]string:
+		return maps.Clone(t)
+	case []string:
+		return slices.Clone(t)
+	case []int:
+		return slices.Clone(t)
+	default:
+		return val
+	}
+}
+
+// Unmarshal unmarshals the entire configuration into the target struct. Fields
+// without a mapstructure tag fall back to their yaml and then json tags. Views
+// created with Sub unmarshal only their subtree.
+func (c *Config) Unmarshal(target interface{}, opts ...DecodeOption) error {
+	r := c.base()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if c.prefix == "" {
+		return decode(cloneValue(r.v.AllSettings()), target, opts...)
+	}
+	return decode(lookup(r.v, c.prefix), target, opts...)
 }
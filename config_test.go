@@ -2,6 +2,7 @@ package config
 
 import (
 	"bytes"
+	"fmt"
 	"os"
 	"strings"
 	"sync"
@@ -523,3 +524,101 @@ func TestNestedConfig(t *testing.T) {
 	assert.Equal(t, "8080", nested.App.Config.Port)
 	assert.Equal(t, "30s", nested.App.Config.Timeout)
 }
+
+// TestConcurrentMutationByCallers tests that values returned to callers can be
+// modified without affecting the configuration or racing with other readers.
+func TestConcurrentMutationByCallers(t *testing.T) {
+	content := []byte(`
+environment: production
+settings:
+  key1: value1
+hosts: [a, b]
+`)
+	tmpfile, err := os.CreateTemp("", "config*.yaml")
+	assert.NoError(t, err)
+	defer os.Remove(tmpfile.Name())
+	_, err = tmpfile.Write(content)
+	assert.NoError(t, err)
+	tmpfile.Close()
+
+	cfg, err := New(WithFilepath(tmpfile.Name()), WithDefault(map[string]interface{}{
+		"labels": map[string]string{"team": "billing"},
+		"zones":  []string{"a", "b"},
+	}))
+	assert.NoError(t, err)
+	assert.NoError(t, cfg.Set("tags", map[string]string{"tier": "web"}))
+
+	var wg sync.WaitGroup
+	numGoroutines := 10
+	iterations := 100
+
+	// Concurrent mutation of returned values alongside reads
+	for i := 0; i < numGoroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < iterations; j++ {
+				s := cfg.GetConfigStruct()
+				s.Settings["key1"] = "mutated"
+				s.Settings[fmt.Sprintf("key%d", i)] = "added"
+
+				settings := cfg.Get("settings").(map[string]interface{})
+				settings["key1"] = "mutated"
+
+				hosts := cfg.GetStringSlice("hosts")
+				hosts[0] = "mutated"
+
+				snap := cfg.Snapshot().Get("settings").(map[string]interface{})
+				snap["key1"] = "mutated"
+
+				_ = cfg.GetStringMapString("settings")
+				labels := cfg.GetStringMapString("labels")
+				labels["team"] = "mutated"
+				tags := cfg.GetStringMapString("tags")
+				tags["tier"] = "mutated"
+				var full ConfigStruct
+				_ = cfg.Unmarshal(&full)
+
+				if zones, err := Value[any](cfg, "zones"); err == nil {
+					zones.([]string)[0] = "mutated"
+				}
+				if raw, err := Value[any](cfg, "hosts"); err == nil {
+					raw.([]interface{})[0] = "mutated"
+				}
+				var all map[string]interface{}
+				if cfg.Unmarshal(&all) == nil {
+					all["zones"].([]string)[0] = "mutated"
+					all["labels"].(map[string]string)["team"] = "mutated"
+				}
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	// Values passed to Diff and Watch callers are copies too
+	other, err := New(WithDefault(map[string]interface{}{"zones": []string{"c"}}))
+	assert.NoError(t, err)
+	for _, ch := range Diff(cfg, other) {
+		if ch.Key == "zones" {
+			ch.Old.([]string)[0] = "mutated"
+		}
+	}
+	sub := cfg.Watch("zones", func(old, new interface{}) {
+		old.([]string)[0] = "mutated"
+		new.([]string)[0] = "mutated"
+	})
+	assert.NoError(t, cfg.Set("zones", []string{"x", "y"}))
+	assert.Equal(t, []string{"x", "y"}, cfg.GetStringSlice("zones"))
+	sub.Close()
+	assert.NoError(t, cfg.Unset("zones"))
+
+	assert.Equal(t, map[string]string{"key1": "value1"}, cfg.GetConfigStruct().Settings)
+	assert.Equal(t, map[string]string{"key1": "value1"}, cfg.GetStringMapString("settings"))
+	assert.Equal(t, []string{"a", "b"}, cfg.GetStringSlice("hosts"))
+	assert.Equal(t, "value1", cfg.Snapshot().GetString("settings.key1"))
+	assert.Equal(t, map[string]string{"team": "billing"}, cfg.GetStringMapString("labels"))
+	assert.Equal(t, map[string]string{"team": "billing"}, cfg.Snapshot().Get("labels"))
+	assert.Equal(t, map[string]string{"tier": "web"}, cfg.GetStringMapString("tags"))
+	assert.Equal(t, []string{"a", "b"}, cfg.GetStringSlice("zones"))
+}
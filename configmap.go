@@ -0,0 +1,256 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// ConfigMapFormat selects how ConfigMapProvider decodes files.
+type ConfigMapFormat int
+
+const (
+	// ConfigMapKeys maps every file to a config key, e.g. the file
+	// "db.host" to "db.host". A single trailing newline is removed from its
+	// content.
+	ConfigMapKeys ConfigMapFormat = iota
+	// ConfigMapDocuments decodes every file as a YAML document, or JSON for
+	// files ending in ".json", and merges them into the root in file name
+	// order.
+	ConfigMapDocuments
+)
+
+// configMapData is the symlink Kubernetes swaps to update a volume.
+const configMapData = "..data"
+
+// ConfigMapProvider loads a directory mounted from a Kubernetes ConfigMap or
+// Secret. Kubernetes writes each update to a new hidden directory and then
+// atomically replaces the "..data" symlink pointing to it, so the provider
+// reads the files through the symlink's current target and never sees a
+// partial update. Plain directories without "..data" are read as they are.
+// As a Watcher it uses fsnotify, or polling where fsnotify is unavailable,
+// and triggers one reload per swap.
+type ConfigMapProvider struct {
+	kind       string
+	dir        string
+	keyOf      func(name string) string
+	format     ConfigMapFormat
+	secret     bool
+	interval   time.Duration
+	maxBackoff time.Duration
+
+	mu      sync.Mutex
+	version string
+	data    map[string]any
+}
+
+// ConfigMapOption configures a ConfigMapProvider.
+type ConfigMapOption func(*ConfigMapProvider)
+
+// WithConfigMapFormat sets how files are decoded. The default is
+// ConfigMapKeys.
+func WithConfigMapFormat(format ConfigMapFormat) ConfigMapOption {
+	return func(p *ConfigMapProvider) {
+		p.format = format
+	}
+}
+
+// WithConfigMapSecret reports every value as secret, as for a mounted
+// Secret.
+func WithConfigMapSecret() ConfigMapOption {
+	return func(p *ConfigMapProvider) {
+		p.secret = true
+	}
+}
+
+// WithConfigMapPollInterval sets how often the directory is checked when
+// fsnotify is unavailable. The default is 10 seconds.
+func WithConfigMapPollInterval(d time.Duration) ConfigMapOption {
+	return func(p *ConfigMapProvider) {
+		p.interval = d
+	}
+}
+
+// NewConfigMapProvider returns a provider that loads the files in dir, e.g.
+// NewConfigMapProvider("/etc/config").
+func NewConfigMapProvider(dir string, opts ...ConfigMapOption) *ConfigMapProvider {
+	p := &ConfigMapProvider{
+		kind:       "configmap",
+		dir:        filepath.Clean(dir),
+		keyOf:      strings.ToLower,
+		interval:   10 * time.Second,
+		maxBackoff: 5 * time.Minute,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// String returns the directory as "configmap:DIR", or "keyperfile:DIR" for
+// a provider returned by NewKeyPerFileProvider.
+func (p *ConfigMapProvider) String() string {
+	return p.kind + ":" + p.dir
+}
+
+// Load reads the files in the directory.
+func (p *ConfigMapProvider) Load(ctx context.Context) (map[string]any, error) {
+	data, _, err := p.read()
+	return data, err
+}
+
+// SecretKeys reports every key if the provider was created with
+// WithConfigMapSecret.
+func (p *ConfigMapProvider) SecretKeys() []string {
+	if !p.secret {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	keys := make([]string, 0, len(p.data))
+	for k := range p.data {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Watch sends an Event when the files in the directory change or cannot be
+// read. Events for the intermediate steps of a swap are ignored.
+func (p *ConfigMapProvider) Watch(ctx context.Context) <-chan Event {
+	w, err := fsnotify.NewWatcher()
+	if err == nil {
+		if err = w.Add(p.dir); err != nil {
+			w.Close()
+		}
+	}
+	if err != nil {
+		return poll(ctx, p.interval, p.maxBackoff, p.check)
+	}
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		defer w.Close()
+		// Catch up on changes made before the watch was added
+		if changed, err := p.check(ctx); (err != nil || changed) && !sendEvent(ctx, events, Event{Err: err}) {
+			return
+		}
+		for {
+			var err error
+			select {
+			case <-ctx.Done():
+				return
+			case _, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				var changed bool
+				if changed, err = p.check(ctx); err == nil && !changed {
+					continue
+				}
+			case err = <-w.Errors:
+			}
+			if !sendEvent(ctx, events, Event{Err: err}) {
+				return
+			}
+		}
+	}()
+	return events
+}
+
+// check reports whether the files changed since the last read. A
+// Kubernetes volume is only read again once "..data" points to a new
+// directory.
+func (p *ConfigMapProvider) check(ctx context.Context) (bool, error) {
+	if version, err := os.Readlink(filepath.Join(p.dir, configMapData)); err == nil {
+		p.mu.Lock()
+		unchanged := version == p.version
+		p.mu.Unlock()
+		if unchanged {
+			return false, nil
+		}
+	}
+	_, changed, err := p.read()
+	return changed, err
+}
+
+// read reads the files through the current target of "..data", or from the
+// directory itself if there is none, and reports whether their data
+// changed. If the target is removed by a concurrent swap, it is resolved
+// again.
+func (p *ConfigMapProvider) read() (map[string]any, bool, error) {
+	for attempt := 0; ; attempt++ {
+		root, version := p.dir, ""
+		if target, err := os.Readlink(filepath.Join(p.dir, configMapData)); err == nil {
+			version = target
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(p.dir, target)
+			}
+			root = target
+		}
+		data, err := p.readDir(root)
+		if errors.Is(err, fs.ErrNotExist) && version != "" && attempt < 3 {
+			continue
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to read %s: %w", p, err)
+		}
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		changed := p.data == nil || !reflect.DeepEqual(p.data, data)
+		p.data = data
+		p.version = version
+		return data, changed, nil
+	}
+}
+
+// readDir decodes the regular files in root. Hidden files, such as the
+// timestamped directories of a Kubernetes volume, are skipped.
+func (p *ConfigMapProvider) readDir(root string) (map[string]any, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	data := make(map[string]any)
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		path := filepath.Join(root, name)
+		// Files of a plain directory may be symlinks
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if p.format == ConfigMapKeys {
+			setPath(data, strings.Split(p.keyOf(name), "."), strings.TrimSuffix(string(b), "\n"))
+			continue
+		}
+		doc, err := parseDocument(b, mime.TypeByExtension(filepath.Ext(name)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+		for k, v := range doc {
+			setPath(data, []string{strings.ToLower(k)}, v)
+		}
+	}
+	return data, nil
+}
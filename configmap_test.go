@@ -0,0 +1,123 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/goleak"
+)
+
+// testConfigMap simulates a Kubernetes ConfigMap volume in a temporary
+// directory.
+type testConfigMap struct {
+	t       *testing.T
+	dir     string
+	version int
+}
+
+// swap performs an update the way the kubelet does: write the files to a new
+// timestamped directory, atomically replace the "..data" symlink, link any
+// new files and remove the previous directory.
+func (m *testConfigMap) swap(files map[string]string) {
+	t := m.t
+	old := fmt.Sprintf("..2025_01_01_00_00_00.%d", m.version)
+	m.version++
+	ts := fmt.Sprintf("..2025_01_01_00_00_00.%d", m.version)
+	assert.NoError(t, os.Mkdir(filepath.Join(m.dir, ts), 0o755))
+	for name, content := range files {
+		assert.NoError(t, os.WriteFile(filepath.Join(m.dir, ts, name), []byte(content), 0o644))
+	}
+	assert.NoError(t, os.Symlink(ts, filepath.Join(m.dir, "..data_tmp")))
+	assert.NoError(t, os.Rename(filepath.Join(m.dir, "..data_tmp"), filepath.Join(m.dir, configMapData)))
+	for name := range files {
+		link := filepath.Join(m.dir, name)
+		if _, err := os.Lstat(link); os.IsNotExist(err) {
+			assert.NoError(t, os.Symlink(filepath.Join(configMapData, name), link))
+		}
+	}
+	assert.NoError(t, os.RemoveAll(filepath.Join(m.dir, old)))
+}
+
+// TestConfigMapProvider tests reading keys and documents.
+func TestConfigMapProvider(t *testing.T) {
+	m := &testConfigMap{t: t, dir: t.TempDir()}
+	m.swap(map[string]string{"environment": "production\n", "DB.Host": "db.internal"})
+	p := NewConfigMapProvider(m.dir)
+	assert.Equal(t, "configmap:"+m.dir, p.String())
+	data, err := p.Load(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"environment": "production", "db": map[string]any{"host": "db.internal"}}, data)
+	assert.Empty(t, p.SecretKeys())
+
+	m.swap(map[string]string{"environment": "staging"})
+	data, err = p.Load(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"environment": "staging"}, data)
+
+	m = &testConfigMap{t: t, dir: t.TempDir()}
+	m.swap(map[string]string{
+		"app.yaml":  "environment: production\ndb:\n  host: db.internal\n",
+		"db.json":   `{"db": {"port": 5432}}`,
+		"README.md": "not: [valid",
+	})
+	_, err = NewConfigMapProvider(m.dir, WithConfigMapFormat(ConfigMapDocuments)).Load(context.Background())
+	assert.ErrorContains(t, err, "failed to parse README.md")
+	m.swap(map[string]string{
+		"app.yaml": "environment: production\ndb:\n  host: db.internal\n",
+		"db.json":  `{"db": {"port": 5432}}`,
+	})
+	p = NewConfigMapProvider(m.dir, WithConfigMapFormat(ConfigMapDocuments), WithConfigMapSecret())
+	data, err = p.Load(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"environment": "production", "db": map[string]any{"host": "db.internal", "port": float64(5432)}}, data)
+	assert.ElementsMatch(t, []string{"environment", "db"}, p.SecretKeys())
+
+	// Plain directories are read as they are
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "environment"), []byte("qa"), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ".hidden"), []byte("ignored"), 0o644))
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "nested"), 0o755))
+	data, err = NewConfigMapProvider(dir).Load(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"environment": "qa"}, data)
+
+	_, err = NewConfigMapProvider(filepath.Join(dir, "missing")).Load(context.Background())
+	assert.ErrorContains(t, err, "no such file or directory")
+}
+
+// TestConfigMapProviderWatch tests that each swap triggers exactly one
+// reload.
+func TestConfigMapProviderWatch(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	m := &testConfigMap{t: t, dir: t.TempDir()}
+	m.swap(map[string]string{"environment": "production", "app.name": "billing"})
+	cfg, err := NewContext(context.Background(), WithProvider(NewConfigMapProvider(m.dir), 10), WithLogger(slog.New(slog.DiscardHandler)))
+	assert.NoError(t, err)
+	defer cfg.Close()
+
+	events := make(chan ChangeEvent, 10)
+	cfg.OnChange(func(e ChangeEvent) {
+		events <- e
+	})
+	for _, env := range []string{"staging", "qa"} {
+		m.swap(map[string]string{"environment": env, "app.name": "billing"})
+		select {
+		case e := <-events:
+			assert.Len(t, e.Changes, 1)
+			assert.Equal(t, env, cfg.GetConfigStruct().Environment)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for reload to %s", env)
+		}
+		time.Sleep(100 * time.Millisecond)
+		assert.Empty(t, events)
+	}
+	assert.Equal(t, "provider:configmap:"+m.dir, cfg.Source("environment"))
+	assert.NoError(t, cfg.Close())
+}
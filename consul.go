@@ -0,0 +1,260 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConsulFormat selects how ConsulProvider decodes values.
+type ConsulFormat int
+
+const (
+	// ConsulScalars maps every key under the prefix to a config key, e.g.
+	// "app/db/host" under prefix "app" to "db.host".
+	ConsulScalars ConsulFormat = iota
+	// ConsulYAML decodes every value under the prefix as a YAML document
+	// placed at the config key derived from its path. A document stored at
+	// the prefix itself is merged into the root.
+	ConsulYAML
+)
+
+// ConsulProvider loads the keys under a Consul KV prefix. As a Watcher it
+// uses blocking queries to trigger a reload as soon as a key changes.
+type ConsulProvider struct {
+	addr       string
+	prefix     string
+	format     ConsulFormat
+	token      string
+	client     *http.Client
+	wait       time.Duration
+	maxBackoff time.Duration
+
+	mu    sync.Mutex
+	index uint64
+	data  map[string]any
+}
+
+// ConsulOption configures a ConsulProvider.
+type ConsulOption func(*ConsulProvider)
+
+// WithConsulFormat sets how values are decoded. The default is ConsulScalars.
+func WithConsulFormat(format ConsulFormat) ConsulOption {
+	return func(p *ConsulProvider) {
+		p.format = format
+	}
+}
+
+// WithConsulToken sends token as the ACL token of every request.
+func WithConsulToken(token string) ConsulOption {
+	return func(p *ConsulProvider) {
+		p.token = token
+	}
+}
+
+// WithConsulWaitTime sets how long a blocking query waits for changes. The
+// default is 5 minutes.
+func WithConsulWaitTime(d time.Duration) ConsulOption {
+	return func(p *ConsulProvider) {
+		p.wait = d
+	}
+}
+
+// WithConsulMaxBackoff caps the delay between retries after consecutive
+// failures. The default is 1 minute.
+func WithConsulMaxBackoff(d time.Duration) ConsulOption {
+	return func(p *ConsulProvider) {
+		p.maxBackoff = d
+	}
+}
+
+// WithConsulClient sets the HTTP client used for requests. Its timeout must be
+// longer than the wait time.
+func WithConsulClient(client *http.Client) ConsulOption {
+	return func(p *ConsulProvider) {
+		p.client = client
+	}
+}
+
+// NewConsulProvider returns a provider that loads the keys under prefix from
+// the Consul agent at addr, e.g. "http://127.0.0.1:8500".
+func NewConsulProvider(addr, prefix string, opts ...ConsulOption) *ConsulProvider {
+	p := &ConsulProvider{
+		addr:       strings.TrimSuffix(addr, "/"),
+		prefix:     strings.Trim(prefix, "/"),
+		client:     &http.Client{},
+		wait:       5 * time.Minute,
+		maxBackoff: time.Minute,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// String returns the prefix as "consul:PREFIX".
+func (p *ConsulProvider) String() string {
+	return "consul:" + p.prefix
+}
+
+// Load returns the keys under the prefix.
+func (p *ConsulProvider) Load(ctx context.Context) (map[string]any, error) {
+	data, _, err := p.query(ctx, 0)
+	return data, err
+}
+
+// Watch issues blocking queries and sends an Event whenever the keys under
+// the prefix change or a query fails. Failed queries are retried with
+// exponential backoff and jitter.
+func (p *ConsulProvider) Watch(ctx context.Context) <-chan Event {
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		failures := 0
+		for {
+			p.mu.Lock()
+			index := p.index
+			p.mu.Unlock()
+			_, changed, err := p.query(ctx, index)
+			if ctx.Err() != nil {
+				return
+			}
+			if err == nil && changed && !sendEvent(ctx, events, Event{}) {
+				return
+			}
+			if err == nil {
+				failures = 0
+				p.mu.Lock()
+				blocking := p.index > 0
+				p.mu.Unlock()
+				if blocking {
+					continue
+				}
+				// Without an index every query returns immediately
+				failures = 1
+			} else {
+				if !sendEvent(ctx, events, Event{Err: err}) {
+					return
+				}
+				failures++
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff(time.Second, p.maxBackoff, failures)):
+			}
+		}
+	}()
+	return events
+}
+
+// consulPair is an entry of a KV response.
+type consulPair struct {
+	Key   string
+	Value []byte
+}
+
+// query reads the prefix, blocking until the index moves past index if it is
+// not zero, and reports whether the data changed.
+func (p *ConsulProvider) query(ctx context.Context, index uint64) (map[string]any, bool, error) {
+	q := url.Values{"recurse": {"true"}}
+	timeout := requestTimeout
+	if index > 0 {
+		q.Set("index", strconv.FormatUint(index, 10))
+		q.Set("wait", fmt.Sprintf("%dms", p.wait.Milliseconds()))
+		// Consul adds up to wait/16 of jitter to blocking queries
+		timeout += p.wait + p.wait/16
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.addr+"/v1/kv/"+p.prefix+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create request: %w", err)
+	}
+	if p.token != "" {
+		req.Header.Set("X-Consul-Token", p.token)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to query %s: %w", p, err)
+	}
+	defer resp.Body.Close()
+
+	var pairs []consulPair
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if err := json.NewDecoder(resp.Body).Decode(&pairs); err != nil {
+			return nil, false, fmt.Errorf("failed to decode %s: %w", p, err)
+		}
+	case http.StatusNotFound:
+		// The prefix has no keys
+	default:
+		return nil, false, fmt.Errorf("failed to query %s: unexpected status %s", p, resp.Status)
+	}
+	data, err := p.decode(pairs)
+	if err != nil {
+		return nil, false, err
+	}
+	newIndex, _ := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	changed := p.data == nil || !reflect.DeepEqual(p.data, data)
+	// Consul may reset the index, e.g. after a snapshot restore
+	if newIndex < p.index {
+		newIndex = 0
+	}
+	p.index = newIndex
+	p.data = data
+	return data, changed, nil
+}
+
+// decode converts KV pairs to a nested map according to the format.
+func (p *ConsulProvider) decode(pairs []consulPair) (map[string]any, error) {
+	data := make(map[string]any)
+	for _, pair := range pairs {
+		// Keys ending with a slash are folders
+		if strings.HasSuffix(pair.Key, "/") {
+			continue
+		}
+		rel, ok := strings.CutPrefix(pair.Key, p.prefix)
+		// Recursive queries match any key starting with the prefix,
+		// including siblings such as "application" for "app"
+		if !ok || (rel != "" && p.prefix != "" && rel[0] != '/') {
+			continue
+		}
+		rel = strings.Trim(rel, "/")
+		var keys []string
+		if rel != "" {
+			keys = strings.Split(strings.ToLower(rel), "/")
+		}
+		if p.format == ConsulScalars {
+			if len(keys) == 0 {
+				continue
+			}
+			setPath(data, keys, string(pair.Value))
+			continue
+		}
+		doc := make(map[string]any)
+		if err := yaml.Unmarshal(pair.Value, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse consul key %s: %w", pair.Key, err)
+		}
+		if len(keys) == 0 {
+			for k, v := range doc {
+				setPath(data, []string{k}, v)
+			}
+			continue
+		}
+		setPath(data, keys, doc)
+	}
+	return data, nil
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/goleak"
+)
+
+// testConsul is a stand-in for the Consul KV HTTP API with blocking queries.
+type testConsul struct {
+	mu      sync.Mutex
+	kv      map[string]string
+	index   uint64
+	changed chan struct{}
+	token   string
+}
+
+func newTestConsul(kv map[string]string) *testConsul {
+	return &testConsul{kv: kv, index: 1, changed: make(chan struct{})}
+}
+
+func (s *testConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	prefix := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+	s.mu.Lock()
+	s.token = r.Header.Get("X-Consul-Token")
+	if index, _ := strconv.ParseUint(r.URL.Query().Get("index"), 10, 64); index >= s.index {
+		wait, _ := time.ParseDuration(r.URL.Query().Get("wait"))
+		changed := s.changed
+		s.mu.Unlock()
+		select {
+		case <-changed:
+		case <-time.After(wait):
+		case <-r.Context().Done():
+			return
+		}
+		s.mu.Lock()
+	}
+	defer s.mu.Unlock()
+
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(s.index, 10))
+	var pairs []consulPair
+	for k, v := range s.kv {
+		if strings.HasPrefix(k, prefix) {
+			pairs = append(pairs, consulPair{Key: k, Value: []byte(v)})
+		}
+	}
+	if len(pairs) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].Key < pairs[j].Key })
+	_ = json.NewEncoder(w).Encode(pairs)
+}
+
+func (s *testConsul) put(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.kv[key] = value
+	s.index++
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// TestConsulProvider tests loading a KV prefix as scalars and documents.
+func TestConsulProvider(t *testing.T) {
+	consul := newTestConsul(map[string]string{
+		"app/":              "",
+		"app/environment":   "production",
+		"app/db/host":       "db.internal",
+		"app/DB/Port":       "5432",
+		"application/other": "ignored",
+		"services/app":      "environment: staging\ndb:\n  host: yaml-db\n",
+		"services/app/pool": "size: 10\n",
+	})
+	srv := httptest.NewServer(consul)
+	defer srv.Close()
+
+	p := NewConsulProvider(srv.URL, "app", WithConsulToken("acl-token"))
+	assert.Equal(t, "consul:app", p.String())
+	data, err := p.Load(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"environment": "production",
+		"db":          map[string]any{"host": "db.internal", "port": "5432"},
+	}, data)
+	assert.Equal(t, "acl-token", consul.token)
+
+	p = NewConsulProvider(srv.URL, "/services/app/", WithConsulFormat(ConsulYAML))
+	data, err = p.Load(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"environment": "staging",
+		"db":          map[string]any{"host": "yaml-db"},
+		"pool":        map[string]any{"size": 10},
+	}, data)
+
+	data, err = NewConsulProvider(srv.URL, "missing").Load(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, data)
+
+	consul.put("services/app/pool", "size: [")
+	_, err = p.Load(context.Background())
+	assert.ErrorContains(t, err, "failed to parse consul key services/app/pool")
+}
+
+// TestConsulProviderWatch tests reloads triggered by blocking queries.
+func TestConsulProviderWatch(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	consul := newTestConsul(map[string]string{
+		"app/environment": "production",
+		"app/app/port":    "8080",
+	})
+	srv := httptest.NewServer(consul)
+	defer srv.Close()
+
+	p := NewConsulProvider(srv.URL, "app", WithConsulWaitTime(time.Minute))
+	cfg, err := NewContext(context.Background(), WithProvider(p, 10), WithLogger(slog.New(slog.DiscardHandler)))
+	assert.NoError(t, err)
+	defer cfg.Close()
+	assert.Equal(t, 8080, cfg.GetInt("app.port"))
+
+	reloads := make(chan int, 10)
+	cfg.OnChange(func(e ChangeEvent) {
+		reloads <- cfg.GetInt("app.port")
+	})
+	consul.put("app/app/port", "9090")
+	select {
+	case port := <-reloads:
+		assert.Equal(t, 9090, port)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for reload")
+	}
+	assert.Equal(t, "provider:consul:app", cfg.Source("app.port"))
+
+	start := time.Now()
+	assert.NoError(t, cfg.Close())
+	assert.Less(t, time.Since(start), time.Second, "Close cancels blocking queries")
+}
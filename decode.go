@@ -0,0 +1,329 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/spf13/viper"
+)
+
+// DecodeOption customizes how Unmarshal, UnmarshalKey and Value decode values.
+type DecodeOption func(*mapstructure.DecoderConfig)
+
+// ErrorUnused makes decoding fail if the input contains keys that do not map
+// to a field of the target, catching typos such as "debg: true".
+func ErrorUnused() DecodeOption {
+	return func(dc *mapstructure.DecoderConfig) {
+		dc.ErrorUnused = true
+	}
+}
+
+// ErrorUnset makes decoding fail if a field of the target has no matching key
+// in the input.
+func ErrorUnset() DecodeOption {
+	return func(dc *mapstructure.DecoderConfig) {
+		dc.ErrorUnset = true
+	}
+}
+
+// TagName sets the struct tag used to look up field names, such as "yaml" or
+// "json". The default is "mapstructure".
+func TagName(name string) DecodeOption {
+	return func(dc *mapstructure.DecoderConfig) {
+		dc.TagName = name
+	}
+}
+
+// Squash flattens all embedded structs into their parent, as if each had a
+// ",squash" tag.
+func Squash() DecodeOption {
+	return func(dc *mapstructure.DecoderConfig) {
+		dc.Squash = true
+	}
+}
+
+// DecodeHook adds decode hooks that run before the default hooks.
+func DecodeHook(hooks ...mapstructure.DecodeHookFunc) DecodeOption {
+	return func(dc *mapstructure.DecoderConfig) {
+		all := make([]mapstructure.DecodeHookFunc, 0, len(hooks)+1)
+		all = append(all, hooks...)
+		all = append(all, dc.DecodeHook)
+		dc.DecodeHook = mapstructure.ComposeDecodeHookFunc(all...)
+	}
+}
+
+// decodeHook returns the default decode hook shared by Unmarshal, UnmarshalKey
+// and Value. It extends viper's defaults with support for
+// encoding.TextUnmarshaler types.
+func decodeHook() mapstructure.DecodeHookFunc {
+	return mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+		mapstructure.TextUnmarshallerHookFunc(),
+	)
+}
+
+// decode decodes input into target using the shared decoder configuration
+// customized by opts.
+func decode(input, target interface{}, opts ...DecodeOption) error {
+	dc := &mapstructure.DecoderConfig{
+		DecodeHook:       decodeHook(),
+		WeaklyTypedInput: true,
+	}
+	for _, opt := range opts {
+		opt(dc)
+	}
+	if dc.TagName == "" || dc.TagName == "mapstructure" {
+		dc.DecodeHook = mapstructure.ComposeDecodeHookFunc(tagFallbackHook, dc.DecodeHook)
+	}
+	// Do not allow options to overwrite the output
+	dc.Result = target
+	decoder, err := mapstructure.NewDecoder(dc)
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(input)
+}
+
+// tagFallbackHook lets struct fields without a mapstructure tag be decoded by
+// their yaml tag, or else their json tag. It renames the matching input keys
+// to the Go field name, which mapstructure then matches case-insensitively,
+// and drops the keys of fields tagged "-". Embedded structs tagged ",inline",
+// or embedded without a name as encoding/json does, are decoded from the keys
+// of their fields as with mapstructure's ",squash".
+func tagFallbackHook(from, to reflect.Type, data interface{}) (interface{}, error) {
+	in, ok := data.(map[string]interface{})
+	if !ok || to.Kind() != reflect.Struct {
+		return data, nil
+	}
+	var out map[string]interface{}
+	copyIn := func() {
+		if out == nil {
+			out = make(map[string]interface{}, len(in))
+			for k, v := range in {
+				out[k] = v
+			}
+		}
+	}
+	var inline []reflect.StructField
+	var skipped []string
+	claimed := make(map[string]bool)
+	for i := 0; i < to.NumField(); i++ {
+		field := to.Field(i)
+		if _, ok := field.Tag.Lookup("mapstructure"); ok || !field.IsExported() {
+			claimed[strings.ToLower(structFieldKey(field))] = true
+			continue
+		}
+		if isInlineField(field, in) {
+			inline = append(inline, field)
+			continue
+		}
+		name := fallbackTagName(field)
+		if name == "-" {
+			skipped = append(skipped, field.Name)
+			continue
+		}
+		claimed[strings.ToLower(field.Name)] = true
+		if name == "" || strings.EqualFold(name, field.Name) {
+			continue
+		}
+		claimed[strings.ToLower(name)] = true
+		for k, v := range in {
+			if !strings.EqualFold(k, name) {
+				continue
+			}
+			copyIn()
+			delete(out, k)
+			out[field.Name] = v
+			break
+		}
+	}
+	// Fields tagged "-" must not be matched by their Go field name
+	for _, name := range skipped {
+		for k := range in {
+			if strings.EqualFold(k, name) && !claimed[strings.ToLower(k)] {
+				copyIn()
+				delete(out, k)
+			}
+		}
+	}
+	// Fields of the outer struct take precedence over inlined ones
+	for _, field := range inline {
+		keys := inlineKeys(field.Type)
+		var sub map[string]interface{}
+		for k, v := range in {
+			lk := strings.ToLower(k)
+			if claimed[lk] || !keys[lk] {
+				continue
+			}
+			copyIn()
+			if sub == nil {
+				sub = make(map[string]interface{})
+				out[field.Name] = sub
+			}
+			delete(out, k)
+			sub[k] = v
+		}
+	}
+	if out == nil {
+		return data, nil
+	}
+	return out, nil
+}
+
+// isInlineField reports whether the struct field is embedded into its parent
+// by a yaml or json ",inline" option, or is an embedded struct without a key
+// of its own in the input.
+func isInlineField(field reflect.StructField, in map[string]interface{}) bool {
+	t := field.Type
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for _, tagName := range []string{"yaml", "json"} {
+		tag, ok := field.Tag.Lookup(tagName)
+		if !ok {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		for _, opt := range strings.Split(opts, ",") {
+			if opt == "inline" {
+				return true
+			}
+		}
+		if name != "" {
+			return false
+		}
+	}
+	if !field.Anonymous {
+		return false
+	}
+	for k := range in {
+		if strings.EqualFold(k, field.Name) {
+			return false
+		}
+	}
+	return true
+}
+
+// inlineKeys returns the lower-cased input keys that the fields of the
+// struct type t are decoded from, including those of its inline fields.
+func inlineKeys(t reflect.Type) map[string]bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	keys := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag, ok := field.Tag.Lookup("mapstructure")
+		if !ok && isInlineField(field, nil) {
+			for k := range inlineKeys(field.Type) {
+				keys[k] = true
+			}
+			continue
+		}
+		if ok && strings.Contains(tag, ",squash") {
+			for k := range inlineKeys(field.Type) {
+				keys[k] = true
+			}
+			continue
+		}
+		keys[strings.ToLower(structFieldKey(field))] = true
+		keys[strings.ToLower(field.Name)] = true
+	}
+	return keys
+}
+
+// structFieldKey returns the key a field is decoded from: the name in its
+// mapstructure tag, else its yaml or json tag, else the field name.
+func structFieldKey(field reflect.StructField) string {
+	if tag, ok := field.Tag.Lookup("mapstructure"); ok {
+		if name, _, _ := strings.Cut(tag, ","); name != "" {
+			return name
+		}
+		return field.Name
+	}
+	if name := fallbackTagName(field); name != "" && name != "-" {
+		return name
+	}
+	return field.Name
+}
+
+// fallbackTagName returns the key name from the yaml or json tag of field, or
+// "-" if the field is skipped.
+func fallbackTagName(field reflect.StructField) string {
+	for _, tagName := range []string{"yaml", "json"} {
+		tag, ok := field.Tag.Lookup(tagName)
+		if !ok {
+			continue
+		}
+		if name, _, _ := strings.Cut(tag, ","); name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// lookup returns a copy of the value at key. Subtrees are resolved from the
+// merged settings so that values from every source, including bound
+// environment variables, are included.
+func lookup(v *viper.Viper, key string) interface{} {
+	val := v.Get(key)
+	if _, ok := val.(map[string]interface{}); !ok {
+		return cloneValue(val)
+	}
+	var node interface{} = v.AllSettings()
+	for _, part := range strings.Split(strings.ToLower(key), ".") {
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return cloneValue(val)
+		}
+		node = m[part]
+	}
+	return cloneValue(node)
+}
+
+// Value decodes the value or subtree at key into T, which may be a scalar,
+// slice, map, struct or encoding.TextUnmarshaler. It returns ErrKeyNotFound if
+// the key is not set.
+func Value[T any](c *Config, key string, opts ...DecodeOption) (T, error) {
+	var out T
+	r := c.base()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if !r.v.IsSet(c.key(key)) {
+		return out, fmt.Errorf("%w: %s", ErrKeyNotFound, key)
+	}
+	if err := decode(lookup(r.v, c.key(key)), &out, opts...); err != nil {
+		return out, fmt.Errorf("failed to decode %s: %w", key, err)
+	}
+	return out, nil
+}
+
+// UnmarshalKey decodes the value or subtree at key into target.
+func (c *Config) UnmarshalKey(key string, target interface{}, opts ...DecodeOption) error {
+	r := c.base()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if err := decode(lookup(r.v, c.key(key)), target, opts...); err != nil {
+		return fmt.Errorf("failed to unmarshal %s: %w", key, err)
+	}
+	return nil
+}
+
+// ValueOr decodes the value at key into T, returning def if the key is not
+// set or cannot be decoded.
+func ValueOr[T any](c *Config, key string, def T) T {
+	out, err := Value[T](c, key)
+	if err != nil {
+		return def
+	}
+	return out
+}
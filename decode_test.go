@@ -0,0 +1,261 @@
+package config
+
+import (
+	"net"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestValueScalars tests Value with scalar, slice and TextUnmarshaler types.
+func TestValueScalars(t *testing.T) {
+	cfg := newTestConfig(t, `
+environment: production
+app:
+  port: "9090"
+  timeout: 30s
+  hosts: a,b,c
+  ip: 10.0.0.1
+`)
+	port, err := Value[int](cfg, "app.port")
+	assert.NoError(t, err)
+	assert.Equal(t, 9090, port)
+
+	timeout, err := Value[time.Duration](cfg, "app.timeout")
+	assert.NoError(t, err)
+	assert.Equal(t, 30*time.Second, timeout)
+
+	hosts, err := Value[[]string](cfg, "app.hosts")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, hosts)
+
+	ip, err := Value[net.IP](cfg, "app.ip")
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.1", ip.String())
+
+	_, err = Value[bool](cfg, "environment")
+	assert.Error(t, err)
+
+	_, err = Value[string](cfg, "missing")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+}
+
+// TestValueStruct tests decoding a subtree merged from several sources.
+func TestValueStruct(t *testing.T) {
+	os.Setenv("CONFIG_APP_NAME", "env-app")
+	defer os.Unsetenv("CONFIG_APP_NAME")
+
+	viper.Reset()
+	cfg := newTestConfig(t, `
+app:
+  port: 8080
+  timeout: 5s
+`, WithEnv("CONFIG"))
+
+	type AppConfig struct {
+		Name    string        `mapstructure:"name"`
+		Port    int           `mapstructure:"port"`
+		Timeout time.Duration `mapstructure:"timeout"`
+	}
+	app, err := Value[AppConfig](cfg, "app")
+	assert.NoError(t, err)
+	assert.Equal(t, AppConfig{Name: "env-app", Port: 8080, Timeout: 5 * time.Second}, app)
+}
+
+// TestValueOr tests ValueOr fallbacks.
+func TestValueOr(t *testing.T) {
+	cfg := newTestConfig(t, `
+environment: staging
+app:
+  port: not-a-port
+`)
+	assert.Equal(t, 8080, ValueOr(cfg, "app.port", 8080))
+	assert.Equal(t, "fallback", ValueOr(cfg, "app.name", "fallback"))
+	assert.Equal(t, "staging", ValueOr(cfg, "environment", "fallback"))
+}
+
+// TestUnmarshalKey tests UnmarshalKey with default options.
+func TestUnmarshalKey(t *testing.T) {
+	cfg := newTestConfig(t, `
+database:
+  host: db.local
+  port: "5432"
+`)
+	type DatabaseConfig struct {
+		Host string `mapstructure:"host"`
+		Port int    `mapstructure:"port"`
+	}
+	var db DatabaseConfig
+	assert.NoError(t, cfg.UnmarshalKey("database", &db))
+	assert.Equal(t, DatabaseConfig{Host: "db.local", Port: 5432}, db)
+
+	var port int
+	assert.NoError(t, cfg.Sub("database").UnmarshalKey("port", &port))
+	assert.Equal(t, 5432, port)
+
+	err := cfg.UnmarshalKey("database", db)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to unmarshal database")
+}
+
+// TestDecodeOptionsStrict tests ErrorUnused and ErrorUnset.
+func TestDecodeOptionsStrict(t *testing.T) {
+	cfg := newTestConfig(t, `
+environment: production
+debg: true
+`)
+	var s ConfigStruct
+	assert.NoError(t, cfg.Unmarshal(&s))
+
+	err := cfg.Unmarshal(&s, ErrorUnused())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "debg")
+
+	type Strict struct {
+		Environment string `mapstructure:"environment"`
+		Region      string `mapstructure:"region"`
+	}
+	var strict Strict
+	err = cfg.Unmarshal(&strict, ErrorUnset())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "region")
+}
+
+// TestDecodeOptionsTagNameAndSquash tests TagName and Squash.
+func TestDecodeOptionsTagNameAndSquash(t *testing.T) {
+	cfg := newTestConfig(t, `
+server:
+  listen_addr: ":8080"
+  read_timeout: 5s
+`)
+	type Timeouts struct {
+		ReadTimeout time.Duration `json:"read_timeout"`
+	}
+	type Server struct {
+		Timeouts
+		ListenAddr string `json:"listen_addr"`
+	}
+	var srv Server
+	assert.NoError(t, cfg.UnmarshalKey("server", &srv, TagName("json"), Squash()))
+	assert.Equal(t, ":8080", srv.ListenAddr)
+	assert.Equal(t, 5*time.Second, srv.ReadTimeout)
+}
+
+// TestDecodeOptionsHook tests extra decode hooks.
+func TestDecodeOptionsHook(t *testing.T) {
+	cfg := newTestConfig(t, `
+level: WARN
+`)
+	type Level int
+	hook := func(from, to reflect.Type, data interface{}) (interface{}, error) {
+		if from.Kind() != reflect.String || to != reflect.TypeOf(Level(0)) {
+			return data, nil
+		}
+		switch data.(string) {
+		case "WARN":
+			return Level(2), nil
+		default:
+			return Level(0), nil
+		}
+	}
+	level, err := Value[Level](cfg, "level", DecodeHook(hook))
+	assert.NoError(t, err)
+	assert.Equal(t, Level(2), level)
+}
+
+// TestUnmarshalTagFallback tests decoding structs tagged only with yaml or json.
+func TestUnmarshalTagFallback(t *testing.T) {
+	cfg := newTestConfig(t, `
+environment: production
+otel:
+  enabled: true
+  service_name: my-service
+  export_timeout: 10s
+`)
+	type FullConfig struct {
+		Environment string `yaml:"environment"`
+		Otel        struct {
+			Enabled       bool          `yaml:"enabled"`
+			ServiceName   string        `yaml:"service_name"`
+			ExportTimeout time.Duration `json:"export_timeout"`
+		} `yaml:"otel"`
+	}
+	var full FullConfig
+	assert.NoError(t, cfg.Unmarshal(&full, ErrorUnused()))
+	assert.Equal(t, "production", full.Environment)
+	assert.True(t, full.Otel.Enabled)
+	assert.Equal(t, "my-service", full.Otel.ServiceName)
+	assert.Equal(t, 10*time.Second, full.Otel.ExportTimeout)
+
+	// mapstructure tags take precedence over yaml tags
+	type Mixed struct {
+		Name  string `mapstructure:"environment" yaml:"name"`
+		Other string `yaml:"-"`
+	}
+	var mixed Mixed
+	assert.NoError(t, cfg.Unmarshal(&mixed))
+	assert.Equal(t, "production", mixed.Name)
+
+	// Fields tagged "-" are not matched by their field name either
+	skipped, err := Value[struct {
+		Enabled bool   `yaml:"enabled"`
+		Skip    string `yaml:"-"`
+		Other   string `json:"-"`
+	}](newTestConfig(t, "plugin:\n  enabled: true\n  skip: value\n  other: value\n"), "plugin", ErrorUnused())
+	assert.NoError(t, err)
+	assert.True(t, skipped.Enabled)
+	assert.Empty(t, skipped.Skip)
+	assert.Empty(t, skipped.Other)
+
+	otel, err := Value[struct {
+		ServiceName string `json:"service_name"`
+	}](cfg, "otel")
+	assert.NoError(t, err)
+	assert.Equal(t, "my-service", otel.ServiceName)
+}
+
+// TestUnmarshalTagFallbackInline tests decoding embedded structs from the
+// keys of their parent.
+func TestUnmarshalTagFallbackInline(t *testing.T) {
+	cfg := newTestConfig(t, `
+environment: production
+db_host: db.internal
+db_port: 5432
+timeout: 5s
+`)
+	type Database struct {
+		Host string `yaml:"db_host"`
+		Port int    `json:"db_port"`
+	}
+	type Timeouts struct {
+		Timeout time.Duration
+	}
+	type Inline struct {
+		Environment string `yaml:"environment"`
+		Database    `yaml:",inline"`
+		*Timeouts
+	}
+	var inline Inline
+	assert.NoError(t, cfg.Unmarshal(&inline, ErrorUnused()))
+	assert.Equal(t, "production", inline.Environment)
+	assert.Equal(t, "db.internal", inline.Host)
+	assert.Equal(t, 5432, inline.Port)
+	if assert.NotNil(t, inline.Timeouts) {
+		assert.Equal(t, 5*time.Second, inline.Timeout)
+	}
+
+	// Fields of the parent take precedence
+	type Shadowed struct {
+		Host     string `json:"db_host"`
+		Database `json:",inline"`
+	}
+	var shadowed Shadowed
+	assert.NoError(t, cfg.Unmarshal(&shadowed))
+	assert.Equal(t, "db.internal", shadowed.Host)
+	assert.Empty(t, shadowed.Database.Host)
+	assert.Equal(t, 5432, shadowed.Port)
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+)
+
+// ChangeKind describes how a key differs between two configurations.
+type ChangeKind string
+
+const (
+	// ChangeAdded is reported for keys that only exist in the new configuration.
+	ChangeAdded ChangeKind = "added"
+	// ChangeRemoved is reported for keys that only exist in the old configuration.
+	ChangeRemoved ChangeKind = "removed"
+	// ChangeModified is reported for keys whose value differs.
+	ChangeModified ChangeKind = "modified"
+)
+
+// Change describes the difference of a single key between two configurations.
+// Values of secret keys are replaced with Redacted.
+type Change struct {
+	Key       string
+	Kind      ChangeKind
+	Old       interface{}
+	New       interface{}
+	OldSource string
+	NewSource string
+}
+
+// Diff compares the leaf values of a and b and returns the changes sorted by
+// key. Views created with Sub are compared relative to their prefix.
+func Diff(a, b *Config) []Change {
+	oldVals, oldSources, oldSecrets := a.diffState()
+	newVals, newSources, newSecrets := b.diffState()
+
+	var changes []Change
+	add := func(key string, kind ChangeKind) {
+		ch := Change{Key: key, Kind: kind, Old: oldVals[key], New: newVals[key]}
+		if kind != ChangeAdded {
+			ch.OldSource = oldSources[joinKey(a.prefix, key)]
+		}
+		if kind != ChangeRemoved {
+			ch.NewSource = newSources[joinKey(b.prefix, key)]
+		}
+		if isSecret(oldSecrets, joinKey(a.prefix, key)) || isSecret(newSecrets, joinKey(b.prefix, key)) {
+			if ch.Old != nil {
+				ch.Old = Redacted
+			}
+			if ch.New != nil {
+				ch.New = Redacted
+			}
+		}
+		changes = append(changes, ch)
+	}
+	for key, oldVal := range oldVals {
+		newVal, ok := newVals[key]
+		switch {
+		case !ok:
+			add(key, ChangeRemoved)
+		case !reflect.DeepEqual(oldVal, newVal):
+			add(key, ChangeModified)
+		}
+	}
+	for key := range newVals {
+		if _, ok := oldVals[key]; !ok {
+			add(key, ChangeAdded)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes
+}
+
+// diffState returns the leaf values, sources and secret keys of c.
+func (c *Config) diffState() (map[string]interface{}, map[string]string, map[string]bool) {
+	r := c.base()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return leaves(r.v, c.prefix), r.sources, r.secretKeys()
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDiff tests comparing two configurations.
+func TestDiff(t *testing.T) {
+	r := NewRegistry()
+	assert.NoError(t, r.Register(Key[string]{Path: "db.password", Secret: true}))
+
+	a := newTestConfig(t, `
+environment: production
+app:
+  name: my-app
+  port: 8080
+db:
+  password: old-secret
+`, WithRegistry(r))
+	b := newTestConfig(t, `
+environment: production
+app:
+  port: 9090
+  workers: 4
+db:
+  password: new-secret
+`, WithRegistry(r))
+
+	changes := Diff(a, b)
+	assert.Len(t, changes, 4)
+	assert.Equal(t, Change{Key: "app.name", Kind: ChangeRemoved, Old: "my-app", OldSource: "file:" + a.v.ConfigFileUsed()}, changes[0])
+	assert.Equal(t, "app.port", changes[1].Key)
+	assert.Equal(t, ChangeModified, changes[1].Kind)
+	assert.Equal(t, 8080, changes[1].Old)
+	assert.Equal(t, 9090, changes[1].New)
+	assert.Equal(t, Change{Key: "app.workers", Kind: ChangeAdded, New: 4, NewSource: "file:" + b.v.ConfigFileUsed()}, changes[2])
+	assert.Equal(t, "db.password", changes[3].Key)
+	assert.Equal(t, Redacted, changes[3].Old)
+	assert.Equal(t, Redacted, changes[3].New)
+
+	assert.Empty(t, Diff(a, a))
+
+	appChanges := Diff(a.Sub("app"), b.Sub("app"))
+	assert.Len(t, appChanges, 3)
+	assert.Equal(t, "name", appChanges[0].Key)
+}
+
+// TestSource tests provenance of configuration values.
+func TestSource(t *testing.T) {
+	os.Setenv("CONFIG_APP_NAME", "env-app")
+	defer os.Unsetenv("CONFIG_APP_NAME")
+
+	viper.Reset()
+	cfg := newTestConfig(t, `
+environment: production
+`, WithDefault(map[string]interface{}{"app.port": 8080}), WithEnv("CONFIG"))
+	assert.NoError(t, cfg.Set("debug", true))
+
+	assert.Equal(t, "file:"+cfg.v.ConfigFileUsed(), cfg.Source("environment"))
+	assert.Equal(t, "env:CONFIG_APP_NAME", cfg.Source("app.name"))
+	assert.Equal(t, "env:CONFIG_APP_NAME", cfg.Sub("app").Source("name"))
+	assert.Equal(t, "default", cfg.Source("app.port"))
+	assert.Equal(t, "override", cfg.Source("debug"))
+	assert.Empty(t, cfg.Source("missing"))
+}
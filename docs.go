@@ -0,0 +1,286 @@
+package config
+
+//go:generate go run ./examples/gendocs -format markdown -out CONFIG.md
+
+import (
+	"encoding"
+	"fmt"
+	"html"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// DocFormat selects the output format of GenerateDocs.
+type DocFormat string
+
+const (
+	// DocFormatMarkdown renders a GitHub-flavored Markdown table.
+	DocFormatMarkdown DocFormat = "markdown"
+	// DocFormatHTML renders an HTML table.
+	DocFormatHTML DocFormat = "html"
+)
+
+// DocOption configures GenerateDocs.
+type DocOption func(*docOptions)
+
+type docOptions struct {
+	envPrefix string
+}
+
+// WithDocEnvPrefix sets the prefix used to derive environment variable names,
+// matching the prefix passed to WithEnv.
+func WithDocEnvPrefix(prefix string) DocOption {
+	return func(o *docOptions) {
+		o.envPrefix = prefix
+	}
+}
+
+// docRow describes a single configuration key.
+type docRow struct {
+	Path        string
+	Env         string
+	Flag        string
+	Type        string
+	Default     string
+	Required    bool
+	Secret      bool
+	Description string
+}
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	timeType            = reflect.TypeOf(time.Time{})
+)
+
+// GenerateDocs renders a reference table for every configuration key described
+// by v, which must be a *Registry, a struct such as ConfigStruct, or a pointer
+// to a struct. Struct keys are read from mapstructure tags, falling back to
+// yaml and json tags and then to the lower-cased field name. The default, desc,
+// secret, flag and env tags fill in the remaining columns. Registry keys
+// always have a default, so they are never listed as required; zero defaults
+// are left blank.
+func GenerateDocs(v any, format DocFormat, opts ...DocOption) (string, error) {
+	o := docOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	rows, err := docRows(v, o)
+	if err != nil {
+		return "", err
+	}
+	switch format {
+	case DocFormatMarkdown:
+		return renderMarkdown(rows), nil
+	case DocFormatHTML:
+		return renderHTML(rows), nil
+	default:
+		return "", fmt.Errorf("unsupported doc format: %s", format)
+	}
+}
+
+// docRows collects the documentation rows for v.
+func docRows(v any, o docOptions) ([]docRow, error) {
+	if r, ok := v.(*Registry); ok {
+		return registryDocRows(r, o), nil
+	}
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot generate docs for %T: not a struct", v)
+	}
+	var rows []docRow
+	collectDocRows(t, "", o, &rows)
+	return rows, nil
+}
+
+// collectDocRows walks the fields of t, appending a row for every leaf key.
+func collectDocRows(t reflect.Type, prefix string, o docOptions, rows *[]docRow) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, squash, required := fieldKey(field)
+		if name == "-" {
+			continue
+		}
+		path := joinKey(prefix, name)
+		if squash {
+			path = prefix
+		}
+		ft := field.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if isNestedStruct(ft) {
+			collectDocRows(ft, path, o, rows)
+			continue
+		}
+		secret := field.Tag.Get("secret") == "true"
+		def := field.Tag.Get("default")
+		if secret && def != "" {
+			def = Redacted
+		}
+		env := field.Tag.Get("env")
+		if env == "" {
+			env = envName(o.envPrefix, path)
+		}
+		flag := field.Tag.Get("flag")
+		if flag == "" {
+			flag = flagName(path)
+		}
+		*rows = append(*rows, docRow{
+			Path:        path,
+			Env:         env,
+			Flag:        flag,
+			Type:        field.Type.String(),
+			Default:     def,
+			Required:    required,
+			Secret:      secret,
+			Description: field.Tag.Get("desc"),
+		})
+	}
+}
+
+// registryDocRows returns a row for every key registered in r. Zero
+// defaults, such as an empty string or a nil slice, are left blank.
+func registryDocRows(r *Registry, o docOptions) []docRow {
+	var rows []docRow
+	for _, k := range r.Keys() {
+		info := k.info()
+		env := info.Env
+		if env == "" {
+			env = envName(o.envPrefix, info.Path)
+		}
+		var def string
+		if v := reflect.ValueOf(info.Default); v.IsValid() && !v.IsZero() {
+			def = fmt.Sprint(info.Default)
+		}
+		if info.Secret && def != "" {
+			def = Redacted
+		}
+		rows = append(rows, docRow{
+			Path:        info.Path,
+			Env:         env,
+			Flag:        flagName(info.Path),
+			Type:        info.Type,
+			Default:     def,
+			Secret:      info.Secret,
+			Description: info.Description,
+		})
+	}
+	return rows
+}
+
+// fieldKey returns the configuration key of a struct field along with its
+// squash and required options.
+func fieldKey(field reflect.StructField) (name string, squash, required bool) {
+	for _, tagName := range []string{"mapstructure", "yaml", "json"} {
+		tag, ok := field.Tag.Lookup(tagName)
+		if !ok {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		for _, opt := range parts[1:] {
+			switch opt {
+			case "squash", "inline":
+				squash = true
+			case "required":
+				required = true
+			}
+		}
+		if parts[0] != "" || squash {
+			return parts[0], squash, required
+		}
+	}
+	return strings.ToLower(field.Name), squash, required
+}
+
+// isNestedStruct reports whether t should be documented as a group of keys
+// rather than as a single value.
+func isNestedStruct(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct || t == timeType {
+		return false
+	}
+	return !reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+// joinKey joins a key prefix and a child key with a dot.
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	if key == "" {
+		return prefix
+	}
+	return prefix + "." + key
+}
+
+// envName returns the environment variable WithEnv binds for key.
+func envName(prefix, key string) string {
+	name := strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+	if prefix == "" {
+		return name
+	}
+	return strings.ToUpper(prefix) + "_" + name
+}
+
+// flagName returns the command-line flag name derived from key.
+func flagName(key string) string {
+	return strings.NewReplacer(".", "-", "_", "-").Replace(key)
+}
+
+var docHeaders = []string{"Key", "Env", "Flag", "Type", "Default", "Required", "Secret", "Description"}
+
+// cells returns the row values in header order.
+func (r docRow) cells() []string {
+	return []string{r.Path, r.Env, r.Flag, r.Type, r.Default, yesNo(r.Required), yesNo(r.Secret), r.Description}
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// renderMarkdown renders rows as a Markdown table.
+func renderMarkdown(rows []docRow) string {
+	var b strings.Builder
+	b.WriteString("| " + strings.Join(docHeaders, " | ") + " |\n")
+	b.WriteString("|" + strings.Repeat(" --- |", len(docHeaders)) + "\n")
+	escape := strings.NewReplacer("|", `\|`, "\n", " ")
+	for _, r := range rows {
+		cells := r.cells()
+		for i, c := range cells {
+			if c != "" && i < 4 {
+				c = "`" + c + "`"
+			}
+			cells[i] = escape.Replace(c)
+		}
+		b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+	return b.String()
+}
+
+// renderHTML renders rows as an HTML table.
+func renderHTML(rows []docRow) string {
+	var b strings.Builder
+	b.WriteString("<table>\n  <thead>\n    <tr>")
+	for _, h := range docHeaders {
+		b.WriteString("<th>" + h + "</th>")
+	}
+	b.WriteString("</tr>\n  </thead>\n  <tbody>\n")
+	for _, r := range rows {
+		b.WriteString("    <tr>")
+		for _, c := range r.cells() {
+			b.WriteString("<td>" + html.EscapeString(c) + "</td>")
+		}
+		b.WriteString("</tr>\n")
+	}
+	b.WriteString("  </tbody>\n</table>\n")
+	return b.String()
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGenerateDocsMarkdown tests Markdown docs for ConfigStruct.
+func TestGenerateDocsMarkdown(t *testing.T) {
+	docs, err := GenerateDocs(ConfigStruct{}, DocFormatMarkdown, WithDocEnvPrefix("config"))
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(docs), "\n")
+	assert.Len(t, lines, 5)
+	assert.Equal(t, "| Key | Env | Flag | Type | Default | Required | Secret | Description |", lines[0])
+	assert.Equal(t, "| `environment` | `CONFIG_ENVIRONMENT` | `environment` | `string` | development | yes | no | Deployment environment name. |", lines[2])
+	assert.Contains(t, lines[4], "`map[string]string`")
+}
+
+// TestGenerateDocsNested tests nested structs, tag fallbacks and secrets.
+func TestGenerateDocsNested(t *testing.T) {
+	type Database struct {
+		Host     string        `yaml:"host" default:"localhost" desc:"Database host."`
+		Password string        `json:"password" default:"hunter2" secret:"true"`
+		Timeout  time.Duration `mapstructure:"timeout" flag:"db-timeout"`
+	}
+	type Service struct {
+		Name     string   `mapstructure:"service_name,required"`
+		Database Database `mapstructure:"database"`
+		Ignored  string   `mapstructure:"-"`
+		Port     int      `env:"PORT"`
+	}
+
+	docs, err := GenerateDocs(&Service{}, DocFormatHTML, WithDocEnvPrefix("APP"))
+	assert.NoError(t, err)
+	assert.Contains(t, docs, "<td>service_name</td><td>APP_SERVICE_NAME</td><td>service-name</td><td>string</td><td></td><td>yes</td>")
+	assert.Contains(t, docs, "<td>database.host</td><td>APP_DATABASE_HOST</td><td>database-host</td><td>string</td><td>localhost</td><td>no</td><td>no</td><td>Database host.</td>")
+	assert.Contains(t, docs, "<td>database.password</td><td>APP_DATABASE_PASSWORD</td><td>database-password</td><td>string</td><td>(redacted)</td><td>no</td><td>yes</td>")
+	assert.Contains(t, docs, "<td>database.timeout</td><td>APP_DATABASE_TIMEOUT</td><td>db-timeout</td><td>time.Duration</td>")
+	assert.Contains(t, docs, "<td>port</td><td>PORT</td>")
+	assert.NotContains(t, docs, "hunter2")
+	assert.NotContains(t, docs, "ignored")
+}
+
+// TestGenerateDocsInvalid tests GenerateDocs with invalid input.
+func TestGenerateDocsInvalid(t *testing.T) {
+	_, err := GenerateDocs(42, DocFormatMarkdown)
+	assert.Error(t, err)
+
+	_, err = GenerateDocs(ConfigStruct{}, DocFormat("pdf"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported doc format")
+}
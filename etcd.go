@@ -0,0 +1,345 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EtcdProvider loads the keys under an etcd v3 prefix through the etcd JSON
+// gateway. As a Watcher it watches the prefix and triggers a reload when a
+// key changes. If the watched revision has been compacted, the prefix is
+// read again and the watch resumes from the current revision; broken watch
+// streams are reopened with exponential backoff.
+type EtcdProvider struct {
+	endpoint   string
+	prefix     string
+	client     *http.Client
+	user       string
+	password   string
+	maxBackoff time.Duration
+
+	mu       sync.Mutex
+	token    string
+	revision int64
+	data     map[string]any
+}
+
+// EtcdOption configures an EtcdProvider.
+type EtcdOption func(*EtcdProvider)
+
+// WithEtcdAuth authenticates requests as user.
+func WithEtcdAuth(user, password string) EtcdOption {
+	return func(p *EtcdProvider) {
+		p.user = user
+		p.password = password
+	}
+}
+
+// WithEtcdMaxBackoff caps the delay between watch reconnects after
+// consecutive failures. The default is 1 minute.
+func WithEtcdMaxBackoff(d time.Duration) EtcdOption {
+	return func(p *EtcdProvider) {
+		p.maxBackoff = d
+	}
+}
+
+// WithEtcdClient sets the HTTP client used for requests, e.g. one configured
+// for TLS. It must not have a timeout, which would end watch streams.
+func WithEtcdClient(client *http.Client) EtcdOption {
+	return func(p *EtcdProvider) {
+		p.client = client
+	}
+}
+
+// NewEtcdProvider returns a provider that loads the keys under prefix from
+// the etcd endpoint, e.g. "http://127.0.0.1:2379". A key "/config/app/db/host"
+// under prefix "/config/app/" maps to "db.host".
+func NewEtcdProvider(endpoint, prefix string, opts ...EtcdOption) *EtcdProvider {
+	p := &EtcdProvider{
+		endpoint:   strings.TrimSuffix(endpoint, "/"),
+		prefix:     prefix,
+		client:     &http.Client{},
+		maxBackoff: time.Minute,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// String returns the prefix as "etcd:PREFIX".
+func (p *EtcdProvider) String() string {
+	return "etcd:" + p.prefix
+}
+
+// Load returns the keys under the prefix.
+func (p *EtcdProvider) Load(ctx context.Context) (map[string]any, error) {
+	data, _, err := p.query(ctx)
+	return data, err
+}
+
+// Watch watches the prefix and sends an Event whenever its keys change or the
+// watch fails.
+func (p *EtcdProvider) Watch(ctx context.Context) <-chan Event {
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		failures := 0
+		for {
+			created, err := p.watch(ctx, events)
+			if ctx.Err() != nil {
+				return
+			}
+			if err == nil && created {
+				// The stream ended after a compaction or was closed by
+				// the server; reopen it right away
+				failures = 0
+				continue
+			}
+			failures++
+			if err != nil && !sendEvent(ctx, events, Event{Err: err}) {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff(time.Second, p.maxBackoff, failures)):
+			}
+		}
+	}()
+	return events
+}
+
+// etcdHeader is the response header of the etcd JSON gateway. 64-bit
+// integers are encoded as strings.
+type etcdHeader struct {
+	Revision int64 `json:"revision,string"`
+}
+
+// etcdKV is a key-value pair; keys and values are base64-encoded.
+type etcdKV struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value"`
+}
+
+type etcdRangeRequest struct {
+	Key      []byte `json:"key"`
+	RangeEnd []byte `json:"range_end"`
+}
+
+type etcdRangeResponse struct {
+	Header etcdHeader `json:"header"`
+	Kvs    []etcdKV   `json:"kvs"`
+}
+
+type etcdWatchRequest struct {
+	CreateRequest struct {
+		etcdRangeRequest
+		StartRevision int64 `json:"start_revision,string"`
+	} `json:"create_request"`
+}
+
+type etcdWatchResponse struct {
+	Result struct {
+		Header          etcdHeader `json:"header"`
+		Created         bool       `json:"created"`
+		Canceled        bool       `json:"canceled"`
+		CompactRevision int64      `json:"compact_revision,string"`
+		CancelReason    string     `json:"cancel_reason"`
+		Events          []struct {
+			Kv etcdKV `json:"kv"`
+		} `json:"events"`
+	} `json:"result"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// query reads the prefix and reports whether the data changed.
+func (p *EtcdProvider) query(ctx context.Context) (map[string]any, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	resp, err := p.post(ctx, "/v3/kv/range", p.rangeRequest())
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+	var out etcdRangeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, false, fmt.Errorf("failed to decode %s: %w", p, err)
+	}
+	data := make(map[string]any)
+	for _, kv := range out.Kvs {
+		rel, ok := strings.CutPrefix(string(kv.Key), p.prefix)
+		// The range covers any key starting with the prefix, including
+		// siblings such as "/config/application" for "/config/app"
+		if !ok || (rel != "" && p.prefix != "" && !strings.HasSuffix(p.prefix, "/") && rel[0] != '/') {
+			continue
+		}
+		rel = strings.Trim(rel, "/")
+		if rel == "" {
+			continue
+		}
+		setPath(data, strings.Split(strings.ToLower(rel), "/"), string(kv.Value))
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	changed := p.data == nil || !reflect.DeepEqual(p.data, data)
+	p.revision = out.Header.Revision
+	p.data = data
+	return data, changed, nil
+}
+
+// watch runs a single watch stream from the revision after the last read. It
+// reports whether the stream was established, and returns when the stream
+// ends. Changes are read back with query, and an Event is sent if the data
+// changed.
+func (p *EtcdProvider) watch(ctx context.Context, events chan<- Event) (bool, error) {
+	var req etcdWatchRequest
+	req.CreateRequest.etcdRangeRequest = p.rangeRequest()
+	p.mu.Lock()
+	req.CreateRequest.StartRevision = p.revision + 1
+	p.mu.Unlock()
+
+	resp, err := p.post(ctx, "/v3/watch", req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	created := false
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var wr etcdWatchResponse
+		if err := dec.Decode(&wr); err != nil {
+			if errors.Is(err, io.EOF) {
+				return created, nil
+			}
+			return created, fmt.Errorf("failed to read watch of %s: %w", p, err)
+		}
+		if wr.Error != nil {
+			return created, fmt.Errorf("failed to watch %s: %s", p, wr.Error.Message)
+		}
+		r := wr.Result
+		created = created || r.Created
+		if r.Canceled && r.CompactRevision == 0 {
+			return created, fmt.Errorf("watch of %s canceled: %s", p, r.CancelReason)
+		}
+		// After a compaction, or once changes arrive, read the prefix again.
+		// A compacted watch is reopened from the new revision by the caller.
+		if r.CompactRevision == 0 && len(r.Events) == 0 {
+			continue
+		}
+		_, changed, err := p.query(ctx)
+		if err != nil {
+			return created, err
+		}
+		if changed && !sendEvent(ctx, events, Event{}) {
+			return created, nil
+		}
+		if r.CompactRevision > 0 {
+			return true, nil
+		}
+	}
+}
+
+// rangeRequest returns the key range covering the prefix.
+func (p *EtcdProvider) rangeRequest() etcdRangeRequest {
+	return etcdRangeRequest{Key: []byte(p.prefix), RangeEnd: prefixEnd(p.prefix)}
+}
+
+// prefixEnd returns the end of the key range starting with prefix.
+func prefixEnd(prefix string) []byte {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	// The prefix is empty or all 0xff bytes: the range covers every key
+	return []byte{0}
+}
+
+// post sends a JSON request to the gateway, authenticating first if needed,
+// and returns the response if its status is 200 OK.
+func (p *EtcdProvider) post(ctx context.Context, path string, in any) (*http.Response, error) {
+	token, err := p.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(in)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", p, err)
+	}
+	if resp.StatusCode == http.StatusOK {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		// The token may have expired; authenticate again next time
+		p.mu.Lock()
+		p.token = ""
+		p.mu.Unlock()
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return nil, fmt.Errorf("failed to query %s: unexpected status %s: %s", p, resp.Status, bytes.TrimSpace(msg))
+}
+
+// authenticate returns the auth token, requesting one if credentials are set
+// and there is no current token.
+func (p *EtcdProvider) authenticate(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	token := p.token
+	p.mu.Unlock()
+	if p.user == "" || token != "" {
+		return token, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	body, _ := json.Marshal(map[string]string{"name": p.user, "password": p.password})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint+"/v3/auth/authenticate", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to authenticate to %s: %w", p, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to authenticate to %s: unexpected status %s", p, resp.Status)
+	}
+	var out struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("failed to decode token: %w", err)
+	}
+	p.mu.Lock()
+	p.token = out.Token
+	p.mu.Unlock()
+	return out.Token, nil
+}
@@ -0,0 +1,242 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/goleak"
+)
+
+// testEtcd is a stand-in for the etcd v3 JSON gateway. It keeps the revision
+// of every put so that watches can replay history and report compaction.
+type testEtcd struct {
+	mu        sync.Mutex
+	kv        map[string]string
+	history   []testEtcdPut
+	revision  int64
+	compacted int64
+	changed   chan struct{}
+	watches   int
+	token     string
+}
+
+type testEtcdPut struct {
+	revision   int64
+	key, value string
+}
+
+func newTestEtcd() *testEtcd {
+	return &testEtcd{kv: make(map[string]string), revision: 1, changed: make(chan struct{})}
+}
+
+func (s *testEtcd) put(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revision++
+	s.kv[key] = value
+	s.history = append(s.history, testEtcdPut{s.revision, key, value})
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+func (s *testEtcd) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/v3/auth/authenticate" {
+		var in struct{ Name, Password string }
+		_ = json.NewDecoder(r.Body).Decode(&in)
+		if in.Name != "root" || in.Password != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"token": "tok"})
+		return
+	}
+	s.mu.Lock()
+	if s.token != "" && r.Header.Get("Authorization") != s.token {
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":"etcdserver: invalid auth token","code":16}`))
+		return
+	}
+	s.mu.Unlock()
+	switch r.URL.Path {
+	case "/v3/kv/range":
+		var in etcdRangeRequest
+		_ = json.NewDecoder(r.Body).Decode(&in)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		var keys []string
+		for k := range s.kv {
+			if k >= string(in.Key) && k < string(in.RangeEnd) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		kvs := []map[string][]byte{}
+		for _, k := range keys {
+			kvs = append(kvs, map[string][]byte{"key": []byte(k), "value": []byte(s.kv[k])})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"header": map[string]string{"revision": fmt.Sprint(s.revision)},
+			"kvs":    kvs,
+		})
+	case "/v3/watch":
+		s.watch(w, r)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (s *testEtcd) watch(w http.ResponseWriter, r *http.Request) {
+	var in etcdWatchRequest
+	_ = json.NewDecoder(r.Body).Decode(&in)
+	key, end := string(in.CreateRequest.Key), string(in.CreateRequest.RangeEnd)
+	send := func(result map[string]any) {
+		s.mu.Lock()
+		result["header"] = map[string]string{"revision": fmt.Sprint(s.revision)}
+		s.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(map[string]any{"result": result})
+		w.(http.Flusher).Flush()
+	}
+	s.mu.Lock()
+	s.watches++
+	compacted := s.compacted
+	s.mu.Unlock()
+	send(map[string]any{"created": true})
+	if in.CreateRequest.StartRevision <= compacted {
+		send(map[string]any{"canceled": true, "compact_revision": fmt.Sprint(compacted)})
+		return
+	}
+	next := in.CreateRequest.StartRevision
+	for {
+		s.mu.Lock()
+		var events []map[string]any
+		for _, p := range s.history {
+			if p.revision >= next && p.key >= key && p.key < end {
+				events = append(events, map[string]any{"kv": map[string][]byte{"key": []byte(p.key), "value": []byte(p.value)}})
+			}
+		}
+		next = s.revision + 1
+		changed := s.changed
+		s.mu.Unlock()
+		if len(events) > 0 {
+			send(map[string]any{"events": events})
+		}
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// TestEtcdProvider tests loading a prefix through the JSON gateway.
+func TestEtcdProvider(t *testing.T) {
+	etcd := newTestEtcd()
+	etcd.put("/config/app/environment", "production")
+	etcd.put("/config/app/app/port", "8080")
+	etcd.put("/config/application/other", "ignored")
+	etcd.token = "tok"
+	srv := httptest.NewServer(etcd)
+	defer srv.Close()
+
+	_, err := NewEtcdProvider(srv.URL, "/config/app/").Load(context.Background())
+	assert.ErrorContains(t, err, "401 Unauthorized: {\"error\":\"etcdserver: invalid auth token\",\"code\":16}")
+	_, err = NewEtcdProvider(srv.URL, "/config/app/", WithEtcdAuth("root", "wrong")).Load(context.Background())
+	assert.ErrorContains(t, err, "failed to authenticate")
+
+	p := NewEtcdProvider(srv.URL, "/config/app/", WithEtcdAuth("root", "pass"))
+	assert.Equal(t, "etcd:/config/app/", p.String())
+	data, err := p.Load(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"environment": "production",
+		"app":         map[string]any{"port": "8080"},
+	}, data)
+	assert.Equal(t, int64(4), p.revision)
+
+	// Sibling prefixes are not loaded without a trailing slash either
+	data, err = NewEtcdProvider(srv.URL, "/config/app", WithEtcdAuth("root", "pass")).Load(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"environment": "production",
+		"app":         map[string]any{"port": "8080"},
+	}, data)
+
+	assert.Equal(t, []byte("/config/app0"), prefixEnd("/config/app/"))
+	assert.Equal(t, []byte{'b'}, prefixEnd("a\xff"))
+	assert.Equal(t, []byte{0}, prefixEnd(""))
+}
+
+// TestEtcdProviderWatch tests reloads, compaction and reconnects.
+func TestEtcdProviderWatch(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	etcd := newTestEtcd()
+	etcd.put("/config/app/environment", "production")
+	srv := httptest.NewServer(etcd)
+	defer srv.Close()
+
+	var logs bytes.Buffer
+	p := NewEtcdProvider(srv.URL, "/config/app/", WithEtcdMaxBackoff(10*time.Millisecond))
+	cfg, err := NewContext(context.Background(), WithProvider(p, 10), WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+	assert.NoError(t, err)
+	defer cfg.Close()
+
+	reloads := make(chan string, 10)
+	cfg.OnChange(func(e ChangeEvent) {
+		reloads <- cfg.GetConfigStruct().Environment
+	})
+	waitReload := func(want string) {
+		t.Helper()
+		select {
+		case env := <-reloads:
+			assert.Equal(t, want, env)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for reload to %s", want)
+		}
+	}
+
+	etcd.put("/config/app/environment", "staging")
+	waitReload("staging")
+	assert.Equal(t, "provider:etcd:/config/app/", cfg.Source("environment"))
+
+	// Changes made while disconnected are replayed from the last revision
+	etcd.mu.Lock()
+	srv.CloseClientConnections()
+	etcd.revision++
+	etcd.kv["/config/app/environment"] = "qa"
+	etcd.history = append(etcd.history, testEtcdPut{etcd.revision, "/config/app/environment", "qa"})
+	etcd.mu.Unlock()
+	waitReload("qa")
+
+	// Changes lost to compaction are picked up by reading the prefix again
+	etcd.mu.Lock()
+	srv.CloseClientConnections()
+	etcd.revision++
+	etcd.kv["/config/app/environment"] = "production"
+	etcd.compacted = etcd.revision
+	etcd.history = nil
+	etcd.mu.Unlock()
+	waitReload("production")
+
+	etcd.put("/config/app/environment", "staging")
+	waitReload("staging")
+	assert.Empty(t, reloads)
+	etcd.mu.Lock()
+	assert.GreaterOrEqual(t, etcd.watches, 3)
+	etcd.mu.Unlock()
+	assert.NoError(t, cfg.Close())
+	assert.NotContains(t, logs.String(), "reload failed")
+	assert.True(t, strings.Count(logs.String(), "config reloaded") >= 4)
+}
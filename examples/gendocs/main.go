@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	config "github.com/T-Prohmpossadhorn/go-core-config"
+)
+
+// Generates the configuration reference for ConfigStruct. Run it through
+// `go generate` (see docs.go) or directly:
+//
+//	go run ./examples/gendocs -format html -prefix CONFIG -out CONFIG.html
+
+func main() {
+	format := flag.String("format", "markdown", "output format: markdown or html")
+	prefix := flag.String("prefix", "CONFIG", "environment variable prefix passed to WithEnv")
+	out := flag.String("out", "", "output file (defaults to stdout)")
+	flag.Parse()
+
+	docs, err := config.GenerateDocs(config.ConfigStruct{}, config.DocFormat(*format), config.WithDocEnvPrefix(*prefix))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to generate docs: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *out == "" {
+		fmt.Print(docs)
+		return
+	}
+	content := "<!-- Code generated by go generate; DO NOT EDIT. -->\n\n" + docs
+	if config.DocFormat(*format) == config.DocFormatMarkdown {
+		content = "# Configuration Reference\n\n" + content
+	}
+	if err := os.WriteFile(*out, []byte(content), 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write %s: %v\n", *out, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,187 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FallbackProvider loads the first of several redundant providers that
+// succeeds, e.g. a region-local config service, then a global one, then a
+// file. Its name, as used in provenance, is the name of the provider that
+// was used. As a Watcher it forwards the events of the active provider and
+// of the providers before it, and periodically retries the providers before
+// the active one, triggering a reload once one of them recovers.
+type FallbackProvider struct {
+	providers  []Provider
+	retry      time.Duration
+	maxBackoff time.Duration
+
+	mu     sync.Mutex
+	active int
+}
+
+// FallbackOption configures a FallbackProvider.
+type FallbackOption func(*FallbackProvider)
+
+// WithFallbackRetryInterval sets how often the providers before the active
+// one are retried. The default is 30 seconds.
+func WithFallbackRetryInterval(d time.Duration) FallbackOption {
+	return func(p *FallbackProvider) {
+		p.retry = d
+	}
+}
+
+// NewFallbackProvider returns a provider that tries providers in order.
+func NewFallbackProvider(providers []Provider, opts ...FallbackOption) *FallbackProvider {
+	p := &FallbackProvider{
+		providers:  providers,
+		retry:      30 * time.Second,
+		maxBackoff: 5 * time.Minute,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// WithFallback adds a FallbackProvider for providers with the given
+// priority, as with WithProvider.
+func WithFallback(priority int, providers ...Provider) Option {
+	return WithProvider(NewFallbackProvider(providers), priority)
+}
+
+// FileProvider loads a local YAML or JSON file as a provider, typically as
+// the last resort of a FallbackProvider.
+type FileProvider struct {
+	path string
+}
+
+// NewFileProvider returns a provider for the file at path. Files ending in
+// ".json" are decoded as JSON and all others as YAML.
+func NewFileProvider(path string) *FileProvider {
+	return &FileProvider{path: path}
+}
+
+// String returns the path as "file:PATH".
+func (p *FileProvider) String() string {
+	return "file:" + p.path
+}
+
+// Load reads and decodes the file.
+func (p *FileProvider) Load(ctx context.Context) (map[string]any, error) {
+	b, err := os.ReadFile(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", p, err)
+	}
+	data, err := parseDocument(b, mime.TypeByExtension(filepath.Ext(p.path)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", p, err)
+	}
+	return data, nil
+}
+
+// String returns the name of the active provider.
+func (p *FallbackProvider) String() string {
+	return p.Active()
+}
+
+// Active returns the name of the provider used by the last successful Load,
+// or of the first provider before any Load.
+func (p *FallbackProvider) Active() string {
+	if len(p.providers) == 0 {
+		return "fallback"
+	}
+	return providerName(p.providers[p.activeIndex()])
+}
+
+// activeIndex returns the index of the active provider.
+func (p *FallbackProvider) activeIndex() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.active
+}
+
+// Load returns the data of the first provider that loads successfully, or
+// the errors of all of them.
+func (p *FallbackProvider) Load(ctx context.Context) (map[string]any, error) {
+	var errs []error
+	for i, provider := range p.providers {
+		data, err := provider.Load(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", providerName(provider), err))
+			continue
+		}
+		p.mu.Lock()
+		p.active = i
+		p.mu.Unlock()
+		return data, nil
+	}
+	return nil, fmt.Errorf("all fallback providers failed: %w", errors.Join(errs...))
+}
+
+// SecretKeys reports the secret keys of the active provider.
+func (p *FallbackProvider) SecretKeys() []string {
+	if len(p.providers) == 0 {
+		return nil
+	}
+	if sp, ok := p.providers[p.activeIndex()].(SecretProvider); ok {
+		return sp.SecretKeys()
+	}
+	return nil
+}
+
+// Watch forwards the events of the active provider and the providers before
+// it, and retries the providers before the active one every retry interval.
+// Errors are only forwarded from the active provider.
+func (p *FallbackProvider) Watch(ctx context.Context) <-chan Event {
+	events := make(chan Event)
+	var wg sync.WaitGroup
+	forward := func(i int, in <-chan Event) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for e := range in {
+				active := p.activeIndex()
+				if i > active || (e.Err != nil && i != active) {
+					continue
+				}
+				if !sendEvent(ctx, events, e) {
+					return
+				}
+			}
+		}()
+	}
+	for i, provider := range p.providers {
+		if w, ok := provider.(Watcher); ok {
+			forward(i, w.Watch(ctx))
+		}
+	}
+	// Retry errors are expected while the preferred providers are down, so
+	// they only delay the next retry
+	forward(-1, poll(ctx, p.retry, p.maxBackoff, p.retryPreferred))
+	go func() {
+		wg.Wait()
+		close(events)
+	}()
+	return events
+}
+
+// retryPreferred loads the providers before the active one and reports
+// whether any of them recovered.
+func (p *FallbackProvider) retryPreferred(ctx context.Context) (bool, error) {
+	var errs []error
+	for _, provider := range p.providers[:p.activeIndex()] {
+		if _, err := provider.Load(ctx); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		return true, nil
+	}
+	return false, errors.Join(errs...)
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/goleak"
+)
+
+// TestFallbackProvider tests loading the first provider that succeeds.
+func TestFallbackProvider(t *testing.T) {
+	regional := newTestProvider("regional", nil)
+	regional.set(nil, errors.New("connection refused"))
+	global := newTestProvider("global", map[string]any{"environment": "global"})
+	file := newTestProvider("file", map[string]any{"environment": "file"})
+
+	p := NewFallbackProvider([]Provider{regional, global, file})
+	assert.Equal(t, "regional", p.Active())
+	data, err := p.Load(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"environment": "global"}, data)
+	assert.Equal(t, "global", p.Active())
+	assert.Equal(t, "global", p.String())
+
+	global.set(nil, errors.New("timeout"))
+	file.set(nil, errors.New("no such file"))
+	_, err = p.Load(context.Background())
+	assert.EqualError(t, err, "all fallback providers failed: regional: connection refused\nglobal: timeout\nfile: no such file")
+	assert.Equal(t, "global", p.Active())
+}
+
+// TestFileProvider tests loading a file as the last provider of a chain.
+func TestFileProvider(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "billing.yaml")
+	p := NewFileProvider(path)
+	assert.Equal(t, "file:"+path, p.String())
+	_, err := p.Load(context.Background())
+	assert.ErrorContains(t, err, "failed to read file:"+path)
+
+	writeFile(t, path, "environment: file\ndb:\n  port: 5432\n")
+	data, err := p.Load(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"environment": "file", "db": map[string]any{"port": 5432}}, data)
+
+	jsonPath := filepath.Join(dir, "billing.json")
+	writeFile(t, jsonPath, `{"environment": "json"`)
+	_, err = NewFileProvider(jsonPath).Load(context.Background())
+	assert.ErrorContains(t, err, "failed to parse file:"+jsonPath)
+
+	remote := newTestProvider("remote", nil)
+	remote.set(nil, errors.New("connection refused"))
+	cfg, err := New(WithFallback(10, remote, p))
+	assert.NoError(t, err)
+	defer cfg.Close()
+	assert.Equal(t, "file", cfg.GetConfigStruct().Environment)
+	assert.Equal(t, 5432, cfg.GetInt("db.port"))
+	assert.Equal(t, "provider:file:"+path, cfg.Source("environment"))
+}
+
+// TestFallbackProviderRecovery tests switching back to a preferred provider
+// once it recovers.
+func TestFallbackProviderRecovery(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	regional := newTestProvider("regional", nil)
+	regional.set(nil, errors.New("connection refused"))
+	global := newTestProvider("global", map[string]any{"environment": "global"})
+	p := NewFallbackProvider([]Provider{regional, global}, WithFallbackRetryInterval(20*time.Millisecond))
+	cfg, err := NewContext(context.Background(), WithProvider(p, 10), WithLogger(slog.New(slog.DiscardHandler)))
+	assert.NoError(t, err)
+	defer cfg.Close()
+	assert.Equal(t, "global", cfg.GetConfigStruct().Environment)
+	assert.Equal(t, "provider:global", cfg.Source("environment"))
+
+	events := make(chan ChangeEvent, 10)
+	cfg.OnChange(func(e ChangeEvent) {
+		events <- e
+	})
+	regional.set(map[string]any{"environment": "regional"}, nil)
+	select {
+	case e := <-events:
+		assert.Equal(t, []Change{{
+			Key: "environment", Kind: ChangeModified,
+			Old: "global", New: "regional",
+			OldSource: "provider:global", NewSource: "provider:regional",
+		}}, e.Changes)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for recovery")
+	}
+	assert.Equal(t, "regional", p.Active())
+
+	// Only events of the active provider and those before it trigger a
+	// reload
+	time.Sleep(50 * time.Millisecond)
+	for len(events) > 0 {
+		<-events
+	}
+	global.events <- Event{}
+	regional.events <- Event{}
+	select {
+	case e := <-events:
+		assert.Empty(t, e.Changes)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for reload")
+	}
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, events)
+	assert.NoError(t, cfg.Close())
+}
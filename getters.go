@@ -0,0 +1,180 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/spf13/cast"
+)
+
+// ErrKeyNotFound is returned by the error-returning getters when a key is not set.
+var ErrKeyNotFound = errors.New("config key not found")
+
+// getE retrieves the value at key and converts it with conv.
+func getE[T any](c *Config, key string, conv func(interface{}) (T, error)) (T, error) {
+	r := c.base()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var zero T
+	if !r.v.IsSet(c.key(key)) {
+		return zero, fmt.Errorf("%w: %s", ErrKeyNotFound, key)
+	}
+	val, err := conv(cloneValue(r.v.Get(c.key(key))))
+	if err != nil {
+		return zero, fmt.Errorf("failed to convert %s: %w", key, err)
+	}
+	return val, nil
+}
+
+// GetString retrieves a string value.
+func (c *Config) GetString(key string) string {
+	val, _ := c.GetStringE(key)
+	return val
+}
+
+// GetStringE retrieves a string value or returns a conversion error.
+func (c *Config) GetStringE(key string) (string, error) {
+	return getE(c, key, cast.ToStringE)
+}
+
+// GetBoolE retrieves a boolean value or returns a conversion error.
+func (c *Config) GetBoolE(key string) (bool, error) {
+	return getE(c, key, cast.ToBoolE)
+}
+
+// GetInt retrieves an int value.
+func (c *Config) GetInt(key string) int {
+	val, _ := c.GetIntE(key)
+	return val
+}
+
+// GetIntE retrieves an int value or returns a conversion error.
+func (c *Config) GetIntE(key string) (int, error) {
+	return getE(c, key, cast.ToIntE)
+}
+
+// GetInt64 retrieves an int64 value.
+func (c *Config) GetInt64(key string) int64 {
+	val, _ := c.GetInt64E(key)
+	return val
+}
+
+// GetInt64E retrieves an int64 value or returns a conversion error.
+func (c *Config) GetInt64E(key string) (int64, error) {
+	return getE(c, key, cast.ToInt64E)
+}
+
+// GetFloat64 retrieves a float64 value.
+func (c *Config) GetFloat64(key string) float64 {
+	val, _ := c.GetFloat64E(key)
+	return val
+}
+
+// GetFloat64E retrieves a float64 value or returns a conversion error.
+func (c *Config) GetFloat64E(key string) (float64, error) {
+	return getE(c, key, cast.ToFloat64E)
+}
+
+// GetDuration retrieves a time.Duration value such as "30s".
+func (c *Config) GetDuration(key string) time.Duration {
+	val, _ := c.GetDurationE(key)
+	return val
+}
+
+// GetDurationE retrieves a time.Duration value or returns a conversion error.
+func (c *Config) GetDurationE(key string) (time.Duration, error) {
+	return getE(c, key, cast.ToDurationE)
+}
+
+// GetTime retrieves a time.Time value.
+func (c *Config) GetTime(key string) time.Time {
+	val, _ := c.GetTimeE(key)
+	return val
+}
+
+// GetTimeE retrieves a time.Time value or returns a conversion error.
+func (c *Config) GetTimeE(key string) (time.Time, error) {
+	return getE(c, key, cast.ToTimeE)
+}
+
+// GetStringSlice retrieves a []string value.
+func (c *Config) GetStringSlice(key string) []string {
+	val, _ := c.GetStringSliceE(key)
+	return val
+}
+
+// GetStringSliceE retrieves a []string value or returns a conversion error.
+func (c *Config) GetStringSliceE(key string) ([]string, error) {
+	return getE(c, key, cast.ToStringSliceE)
+}
+
+// GetIntSlice retrieves a []int value.
+func (c *Config) GetIntSlice(key string) []int {
+	val, _ := c.GetIntSliceE(key)
+	return val
+}
+
+// GetIntSliceE retrieves a []int value or returns a conversion error.
+func (c *Config) GetIntSliceE(key string) ([]int, error) {
+	return getE(c, key, cast.ToIntSliceE)
+}
+
+// GetSizeInBytes retrieves a size such as "512kb" or "1GB" in bytes.
+func (c *Config) GetSizeInBytes(key string) uint {
+	val, _ := c.GetSizeInBytesE(key)
+	return val
+}
+
+// GetSizeInBytesE retrieves a size in bytes or returns a conversion error.
+func (c *Config) GetSizeInBytesE(key string) (uint, error) {
+	return getE(c, key, toSizeInBytesE)
+}
+
+// GetStringMap retrieves a map[string]interface{} value.
+func (c *Config) GetStringMap(key string) map[string]interface{} {
+	val, _ := c.GetStringMapE(key)
+	return val
+}
+
+// GetStringMapE retrieves a map[string]interface{} value or returns a conversion error.
+func (c *Config) GetStringMapE(key string) (map[string]interface{}, error) {
+	return getE(c, key, cast.ToStringMapE)
+}
+
+// GetStringMapStringE retrieves a map[string]string value or returns a conversion error.
+func (c *Config) GetStringMapStringE(key string) (map[string]string, error) {
+	return getE(c, key, cast.ToStringMapStringE)
+}
+
+// toSizeInBytesE converts sizes such as 1024, "1024", "10kb", "5 MB" or "1GB"
+// to bytes. Units are powers of 1024, matching viper's GetSizeInBytes.
+func toSizeInBytesE(i interface{}) (uint, error) {
+	s, ok := i.(string)
+	if !ok {
+		return cast.ToUintE(i)
+	}
+	s = strings.ToLower(strings.TrimSpace(s))
+	multiplier := uint64(1)
+	for _, unit := range []struct {
+		suffix string
+		factor uint64
+	}{{"gb", 1 << 30}, {"mb", 1 << 20}, {"kb", 1 << 10}, {"b", 1}} {
+		if strings.HasSuffix(s, unit.suffix) {
+			multiplier = unit.factor
+			s = strings.TrimRightFunc(strings.TrimSuffix(s, unit.suffix), unicode.IsSpace)
+			break
+		}
+	}
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: %w", i, err)
+	}
+	if n > ^uint64(0)/multiplier || n*multiplier > uint64(^uint(0)) {
+		return 0, fmt.Errorf("size %q overflows uint", i)
+	}
+	return uint(n * multiplier), nil
+}
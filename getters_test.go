@@ -0,0 +1,109 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestConfig creates a Config from YAML content written to a temporary file.
+func newTestConfig(t testing.TB, content string, opts ...Option) *Config {
+	t.Helper()
+	tmpfile, err := os.CreateTemp("", "config*.yaml")
+	assert.NoError(t, err)
+	t.Cleanup(func() { os.Remove(tmpfile.Name()) })
+	_, err = tmpfile.Write([]byte(content))
+	assert.NoError(t, err)
+	tmpfile.Close()
+
+	cfg, err := New(append([]Option{WithFilepath(tmpfile.Name())}, opts...)...)
+	if err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+	return cfg
+}
+
+// TestTypedGetters tests the typed getters with convertible values.
+func TestTypedGetters(t *testing.T) {
+	cfg := newTestConfig(t, `
+environment: production
+app:
+  port: "9090"
+  workers: 4
+  ratio: 0.75
+  timeout: 30s
+  started: 2025-05-18T10:00:00Z
+  hosts: [a, b]
+  ids: ["1", 2]
+  buffer: 10mb
+  limits:
+    cpu: 2
+`)
+	assert.Equal(t, "production", cfg.GetString("environment"))
+	assert.Equal(t, 9090, cfg.GetInt("app.port"))
+	assert.Equal(t, int64(4), cfg.GetInt64("app.workers"))
+	assert.Equal(t, 0.75, cfg.GetFloat64("app.ratio"))
+	assert.Equal(t, 30*time.Second, cfg.GetDuration("app.timeout"))
+	assert.Equal(t, time.Date(2025, 5, 18, 10, 0, 0, 0, time.UTC), cfg.GetTime("app.started").UTC())
+	assert.Equal(t, []string{"a", "b"}, cfg.GetStringSlice("app.hosts"))
+	assert.Equal(t, []int{1, 2}, cfg.GetIntSlice("app.ids"))
+	assert.Equal(t, uint(10<<20), cfg.GetSizeInBytes("app.buffer"))
+	assert.Equal(t, map[string]interface{}{"cpu": 2}, cfg.GetStringMap("app.limits"))
+}
+
+// TestTypedGettersErrors tests the error-returning getter variants.
+func TestTypedGettersErrors(t *testing.T) {
+	cfg := newTestConfig(t, `
+environment: production
+app:
+  port: not-a-port
+  timeout: forever
+  buffer: 3 parsecs
+`)
+	_, err := cfg.GetBoolE("environment")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to convert environment")
+
+	_, err = cfg.GetIntE("app.port")
+	assert.Error(t, err)
+
+	_, err = cfg.GetDurationE("app.timeout")
+	assert.Error(t, err)
+
+	_, err = cfg.GetSizeInBytesE("app.buffer")
+	assert.Error(t, err)
+
+	_, err = cfg.GetStringMapE("environment")
+	assert.Error(t, err)
+
+	_, err = cfg.GetFloat64E("missing.key")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	// Non-E variants keep returning zero values
+	assert.Equal(t, 0, cfg.GetInt("app.port"))
+	assert.Zero(t, cfg.GetDuration("missing.key"))
+
+	port, err := cfg.GetStringE("app.port")
+	assert.NoError(t, err)
+	assert.Equal(t, "not-a-port", port)
+}
+
+// TestToSizeInBytes tests size parsing.
+func TestToSizeInBytes(t *testing.T) {
+	cases := map[interface{}]uint{
+		1024:    1024,
+		"2048":  2048,
+		"5b":    5,
+		"10 KB": 10 << 10,
+		"1GB":   1 << 30,
+	}
+	for in, want := range cases {
+		got, err := toSizeInBytesE(in)
+		assert.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+	_, err := toSizeInBytesE("-1mb")
+	assert.Error(t, err)
+}
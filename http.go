@@ -0,0 +1,195 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// HTTPProvider loads a YAML or JSON document from a URL. It caches the
+// document with its ETag and sends If-None-Match, so unchanged documents are
+// not transferred again. As a Watcher it polls the URL and triggers a reload
+// when the document changes.
+type HTTPProvider struct {
+	url        string
+	client     *http.Client
+	token      string
+	interval   time.Duration
+	maxBackoff time.Duration
+
+	mu   sync.Mutex
+	etag string
+	data map[string]any
+}
+
+// HTTPOption configures an HTTPProvider.
+type HTTPOption func(*HTTPProvider)
+
+// WithHTTPInterval sets how often the document is polled. The default is 30
+// seconds.
+func WithHTTPInterval(d time.Duration) HTTPOption {
+	return func(p *HTTPProvider) {
+		p.interval = d
+	}
+}
+
+// WithHTTPMaxBackoff caps the delay between polls after consecutive failures.
+// The default is 5 minutes.
+func WithHTTPMaxBackoff(d time.Duration) HTTPOption {
+	return func(p *HTTPProvider) {
+		p.maxBackoff = d
+	}
+}
+
+// WithHTTPBearerToken sends token in the Authorization header.
+func WithHTTPBearerToken(token string) HTTPOption {
+	return func(p *HTTPProvider) {
+		p.token = token
+	}
+}
+
+// WithHTTPTLSConfig sets the TLS configuration, e.g. client certificates for
+// mutual TLS or a private root CA.
+func WithHTTPTLSConfig(cfg *tls.Config) HTTPOption {
+	return func(p *HTTPProvider) {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = cfg
+		p.client = &http.Client{Transport: transport, Timeout: p.client.Timeout}
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for requests.
+func WithHTTPClient(client *http.Client) HTTPOption {
+	return func(p *HTTPProvider) {
+		p.client = client
+	}
+}
+
+// NewHTTPProvider returns a provider that loads the document at rawURL.
+func NewHTTPProvider(rawURL string, opts ...HTTPOption) *HTTPProvider {
+	p := &HTTPProvider{
+		url:        rawURL,
+		client:     &http.Client{Timeout: 30 * time.Second},
+		interval:   30 * time.Second,
+		maxBackoff: 5 * time.Minute,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// String returns the URL of the document without credentials.
+func (p *HTTPProvider) String() string {
+	if u, err := url.Parse(p.url); err == nil {
+		return u.Redacted()
+	}
+	return p.url
+}
+
+// Load returns the document, fetching it again only if it has changed.
+func (p *HTTPProvider) Load(ctx context.Context) (map[string]any, error) {
+	data, _, err := p.fetch(ctx)
+	return data, err
+}
+
+// Watch polls the document and sends an Event when it changes or cannot be
+// fetched. After a failure, the next poll is delayed with exponential backoff
+// and jitter.
+func (p *HTTPProvider) Watch(ctx context.Context) <-chan Event {
+	return poll(ctx, p.interval, p.maxBackoff, func(ctx context.Context) (bool, error) {
+		_, changed, err := p.fetch(ctx)
+		return changed, err
+	})
+}
+
+// backoff returns a jittered delay that doubles with every failure, starting
+// at base and capped at max. The result is spread over [d/2, d] so that many
+// instances do not retry in lockstep.
+func backoff(base, max time.Duration, failures int) time.Duration {
+	d := base
+	for i := 0; i < failures && d < max; i++ {
+		d *= 2
+	}
+	d = min(d, max)
+	return d/2 + rand.N(d/2+1)
+}
+
+// fetch requests the document and reports whether its content changed.
+func (p *HTTPProvider) fetch(ctx context.Context) (map[string]any, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/yaml, application/json")
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	}
+	p.mu.Lock()
+	if p.etag != "" && p.data != nil {
+		req.Header.Set("If-None-Match", p.etag)
+	}
+	p.mu.Unlock()
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to fetch %s: %w", p, err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return p.data, false, nil
+	case http.StatusOK:
+	default:
+		return nil, false, fmt.Errorf("failed to fetch %s: unexpected status %s", p, resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read %s: %w", p, err)
+	}
+	data, err := parseDocument(body, resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to parse %s: %w", p, err)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	changed := p.data == nil || !reflect.DeepEqual(p.data, data)
+	p.etag = resp.Header.Get("ETag")
+	p.data = data
+	return data, changed, nil
+}
+
+// parseDocument decodes a JSON document if contentType says so, and a YAML
+// document otherwise. An empty document yields an empty map.
+func parseDocument(body []byte, contentType string) (map[string]any, error) {
+	data := make(map[string]any)
+	if len(bytes.TrimSpace(body)) == 0 {
+		return data, nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") {
+		if err := json.Unmarshal(body, &data); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+	if err := yaml.Unmarshal(body, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
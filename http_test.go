@@ -0,0 +1,168 @@
+package config
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/goleak"
+)
+
+// testDocServer serves a configuration document with an ETag.
+type testDocServer struct {
+	mu          sync.Mutex
+	body        string
+	version     int
+	contentType string
+	status      int
+	requests    int
+	notModified int
+	auth        string
+}
+
+func (s *testDocServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+	s.auth = r.Header.Get("Authorization")
+	if s.status != 0 {
+		w.WriteHeader(s.status)
+		return
+	}
+	etag := fmt.Sprintf(`"v%d"`, s.version)
+	if r.Header.Get("If-None-Match") == etag {
+		s.notModified++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	if s.contentType != "" {
+		w.Header().Set("Content-Type", s.contentType)
+	}
+	_, _ = w.Write([]byte(s.body))
+}
+
+func (s *testDocServer) set(body string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.body = body
+	s.status = status
+	s.version++
+}
+
+// TestHTTPProvider tests loading documents with ETag caching.
+func TestHTTPProvider(t *testing.T) {
+	doc := &testDocServer{body: "environment: production\napp:\n  port: 8080\n"}
+	srv := httptest.NewServer(doc)
+	defer srv.Close()
+
+	p := NewHTTPProvider(srv.URL+"/app.yaml", WithHTTPBearerToken("s3cret"))
+	assert.Equal(t, srv.URL+"/app.yaml", p.String())
+	data, err := p.Load(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"environment": "production", "app": map[string]any{"port": 8080}}, data)
+	assert.Equal(t, "Bearer s3cret", doc.auth)
+
+	data, err = p.Load(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 8080, data["app"].(map[string]any)["port"])
+	assert.Equal(t, 2, doc.requests)
+	assert.Equal(t, 1, doc.notModified)
+
+	doc.set(`{"environment": "staging"}`, 0)
+	doc.contentType = "application/json; charset=utf-8"
+	data, err = p.Load(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"environment": "staging"}, data)
+
+	doc.set("", http.StatusServiceUnavailable)
+	_, err = p.Load(context.Background())
+	assert.ErrorContains(t, err, "unexpected status 503 Service Unavailable")
+
+	doc.set("environment: [unclosed", 0)
+	doc.contentType = ""
+	_, err = p.Load(context.Background())
+	assert.ErrorContains(t, err, "failed to parse")
+}
+
+// TestHTTPProviderTLS tests client certificates for mutual TLS.
+func TestHTTPProviderTLS(t *testing.T) {
+	doc := &testDocServer{body: "environment: production\n"}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		doc.ServeHTTP(w, r)
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	defer srv.Close()
+
+	roots := srv.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+	_, err := NewHTTPProvider(srv.URL, WithHTTPTLSConfig(&tls.Config{RootCAs: roots})).Load(context.Background())
+	assert.Error(t, err)
+
+	p := NewHTTPProvider(srv.URL, WithHTTPTLSConfig(&tls.Config{
+		RootCAs:      roots,
+		Certificates: srv.TLS.Certificates,
+	}))
+	data, err := p.Load(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "production", data["environment"])
+}
+
+// TestHTTPProviderWatch tests reloads triggered by polling.
+func TestHTTPProviderWatch(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	doc := &testDocServer{body: "environment: production\n"}
+	srv := httptest.NewServer(doc)
+	defer srv.Close()
+
+	p := NewHTTPProvider(srv.URL, WithHTTPInterval(10*time.Millisecond), WithHTTPMaxBackoff(20*time.Millisecond))
+	cfg, err := NewContext(context.Background(), WithProvider(p, 10), WithLogger(slog.New(slog.DiscardHandler)))
+	assert.NoError(t, err)
+	defer srv.CloseClientConnections()
+	defer cfg.Close()
+
+	reloads := make(chan string, 10)
+	cfg.OnChange(func(e ChangeEvent) {
+		reloads <- cfg.GetConfigStruct().Environment
+	})
+
+	doc.set("", http.StatusInternalServerError)
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, reloads, "failed polls keep the current state")
+	assert.Equal(t, "production", cfg.GetConfigStruct().Environment)
+
+	doc.set("environment: staging\n", 0)
+	select {
+	case env := <-reloads:
+		assert.Equal(t, "staging", env)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for reload")
+	}
+	assert.Equal(t, "provider:"+srv.URL, cfg.Source("environment"))
+	assert.NoError(t, cfg.Close())
+}
+
+// TestBackoff tests the delay between retries.
+func TestBackoff(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		d := backoff(time.Second, 10*time.Second, 1)
+		assert.True(t, d >= time.Second && d <= 2*time.Second, d)
+		d = backoff(time.Second, 10*time.Second, 10)
+		assert.True(t, d >= 5*time.Second && d <= 10*time.Second, d)
+	}
+}
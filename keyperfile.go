@@ -0,0 +1,41 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// NewKeyPerFileProvider returns a provider for a directory with one file per
+// key, such as Docker's /run/secrets or a systemd credentials directory.
+// Each file name is a config key, with "__" as an alternative separator, so
+// both "db.password" and "DB__PASSWORD" map to "db.password". mapping maps
+// file names to keys where the name is not usable as a key. File contents
+// are the values, without a single trailing newline, and are reported as
+// secret. Kubernetes volumes are read as with NewConfigMapProvider.
+func NewKeyPerFileProvider(dir string, mapping map[string]string) *ConfigMapProvider {
+	p := NewConfigMapProvider(dir, WithConfigMapSecret())
+	p.kind = "keyperfile"
+	p.keyOf = func(name string) string {
+		if key, ok := mapping[name]; ok {
+			return strings.ToLower(key)
+		}
+		return strings.ToLower(strings.ReplaceAll(name, "__", "."))
+	}
+	return p
+}
+
+// WithKeyPerFileDir adds a NewKeyPerFileProvider for dir with priority 0,
+// as with WithProvider. If dir is empty, the directory in
+// $CREDENTIALS_DIRECTORY is used, which systemd sets for services with
+// credentials; without it, the option does nothing.
+func WithKeyPerFileDir(dir string, mapping map[string]string) Option {
+	if dir == "" {
+		dir = os.Getenv("CREDENTIALS_DIRECTORY")
+	}
+	if dir == "" {
+		return func(*Config) error {
+			return nil
+		}
+	}
+	return WithProvider(NewKeyPerFileProvider(dir, mapping), 0)
+}
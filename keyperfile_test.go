@@ -0,0 +1,64 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestKeyPerFileProvider tests mapping file names to keys.
+func TestKeyPerFileProvider(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"DB__PASSWORD": "hunter2\n",
+		"db.user":      "billing",
+		"api-token":    "abc123",
+	} {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+	p := NewKeyPerFileProvider(dir, map[string]string{"api-token": "API.Token"})
+	assert.Equal(t, "keyperfile:"+dir, p.String())
+	data, err := p.Load(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"db":  map[string]any{"password": "hunter2", "user": "billing"},
+		"api": map[string]any{"token": "abc123"},
+	}, data)
+	assert.ElementsMatch(t, []string{"db", "api"}, p.SecretKeys())
+}
+
+// TestWithKeyPerFileDir tests reading systemd credentials.
+func TestWithKeyPerFileDir(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "environment"), []byte("production\n"), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "DB__PASSWORD"), []byte("hunter2"), 0o600))
+	os.Setenv("CREDENTIALS_DIRECTORY", dir)
+	defer os.Unsetenv("CREDENTIALS_DIRECTORY")
+
+	cfg, err := New(WithKeyPerFileDir("", nil))
+	assert.NoError(t, err)
+	defer cfg.Close()
+	assert.Equal(t, "production", cfg.GetConfigStruct().Environment)
+	assert.Equal(t, "hunter2", cfg.GetString("db.password"))
+	assert.Equal(t, "provider:keyperfile:"+dir, cfg.Source("db.password"))
+
+	var changes []Change
+	cfg.OnChange(func(e ChangeEvent) {
+		changes = e.Changes
+	})
+	assert.NoError(t, cfg.Set("db.password", "correct-horse"))
+	assert.Equal(t, []Change{{
+		Key: "db.password", Kind: ChangeModified,
+		Old: Redacted, New: Redacted,
+		OldSource: "provider:keyperfile:" + dir, NewSource: "override",
+	}}, changes)
+
+	os.Unsetenv("CREDENTIALS_DIRECTORY")
+	cfg, err = New(WithKeyPerFileDir("", nil), WithDefault(map[string]interface{}{"environment": "test"}))
+	assert.NoError(t, err)
+	defer cfg.Close()
+	assert.Empty(t, cfg.GetString("db.password"))
+}
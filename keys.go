@@ -0,0 +1,172 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Key declares a typed configuration key. Register it with Register or
+// Registry.Register so that New applies its default, binds its environment
+// variable and validates its value.
+type Key[T any] struct {
+	Path        string
+	Default     T
+	Env         string
+	Description string
+	Secret      bool
+	Validate    func(T) error
+}
+
+// Get retrieves the value of the key, falling back to its default if the key
+// is unset or cannot be decoded. Paths are absolute, even when c is a view.
+func (k Key[T]) Get(c *Config) T {
+	return ValueOr(c.base(), k.Path, k.Default)
+}
+
+// Lookup retrieves the value of the key or returns a decode error.
+func (k Key[T]) Lookup(c *Config) (T, error) {
+	return Value[T](c.base(), k.Path)
+}
+
+// info returns the registry metadata of the key.
+func (k Key[T]) info() keyInfo {
+	return keyInfo{
+		Path:        strings.ToLower(k.Path),
+		Env:         k.Env,
+		Default:     k.Default,
+		Type:        reflect.TypeOf((*T)(nil)).Elem().String(),
+		Description: k.Description,
+		Secret:      k.Secret,
+	}
+}
+
+// check decodes the key from c and runs its Validate function.
+func (k Key[T]) check(c *Config) error {
+	val, err := k.Lookup(c)
+	if err != nil {
+		return err
+	}
+	if k.Validate == nil {
+		return nil
+	}
+	if err := k.Validate(val); err != nil {
+		return fmt.Errorf("invalid value for %s: %w", k.Path, err)
+	}
+	return nil
+}
+
+// KeyDescriptor is implemented by Key and can be added to a Registry.
+type KeyDescriptor interface {
+	info() keyInfo
+	check(c *Config) error
+}
+
+// keyInfo holds the type-erased metadata of a Key.
+type keyInfo struct {
+	Path        string
+	Env         string
+	Default     interface{}
+	Type        string
+	Description string
+	Secret      bool
+}
+
+// Registry holds key descriptors that New uses for defaults, environment
+// binding, validation and documentation.
+type Registry struct {
+	mu    sync.RWMutex
+	keys  []KeyDescriptor
+	paths map[string]struct{}
+}
+
+// DefaultRegistry is used by New unless WithRegistry is given.
+var DefaultRegistry = NewRegistry()
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{paths: make(map[string]struct{})}
+}
+
+// Register adds keys to the registry. It fails if a path is empty or already
+// registered.
+func (r *Registry) Register(keys ...KeyDescriptor) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, k := range keys {
+		path := k.info().Path
+		if path == "" {
+			return fmt.Errorf("cannot register key: empty path")
+		}
+		if _, ok := r.paths[path]; ok {
+			return fmt.Errorf("key %s is already registered", path)
+		}
+		r.paths[path] = struct{}{}
+		r.keys = append(r.keys, k)
+	}
+	return nil
+}
+
+// Keys returns the registered keys in registration order.
+func (r *Registry) Keys() []KeyDescriptor {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]KeyDescriptor(nil), r.keys...)
+}
+
+// Register adds k to DefaultRegistry and returns it, so keys can be declared
+// as package-level variables. It panics if the path is already registered.
+func Register[T any](k Key[T]) Key[T] {
+	if err := DefaultRegistry.Register(k); err != nil {
+		panic(err)
+	}
+	return k
+}
+
+// WithRegistry uses r instead of DefaultRegistry.
+func WithRegistry(r *Registry) Option {
+	return func(c *Config) error {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.registry = r
+		return nil
+	}
+}
+
+// applyRegistry applies defaults and environment bindings of the registered
+// keys and validates their values.
+func (c *Config) applyRegistry() error {
+	if c.registry == nil {
+		return nil
+	}
+	keys := c.registry.Keys()
+	c.mu.Lock()
+	for _, k := range keys {
+		info := k.info()
+		if !c.v.IsSet(info.Path) {
+			c.v.SetDefault(info.Path, info.Default)
+		}
+		env := info.Env
+		if env == "" && c.envPrefix != "" {
+			env = envName(c.envPrefix, info.Path)
+		}
+		if env != "" {
+			if err := c.v.BindEnv(info.Path, env); err != nil {
+				c.mu.Unlock()
+				return fmt.Errorf("failed to bind env var %s: %w", info.Path, err)
+			}
+		}
+	}
+	err := c.v.Unmarshal(&c.configStruct)
+	c.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal ConfigStruct: %w", err)
+	}
+	for _, k := range keys {
+		if err := k.check(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
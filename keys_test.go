@@ -0,0 +1,113 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRegistryDefaultsAndEnv tests registry defaults and environment binding.
+func TestRegistryDefaultsAndEnv(t *testing.T) {
+	os.Setenv("APP_PORT", "9090")
+	defer os.Unsetenv("APP_PORT")
+
+	port := Key[int]{Path: "app.port", Default: 8080, Env: "APP_PORT"}
+	timeout := Key[time.Duration]{Path: "app.timeout", Default: 5 * time.Second}
+	name := Key[string]{Path: "app.name", Default: "default-app"}
+	r := NewRegistry()
+	assert.NoError(t, r.Register(port, timeout, name))
+
+	viper.Reset()
+	cfg := newTestConfig(t, `
+app:
+  name: file-app
+`, WithRegistry(r))
+	assert.Equal(t, 9090, port.Get(cfg))
+	assert.Equal(t, 5*time.Second, timeout.Get(cfg))
+	assert.Equal(t, "file-app", name.Get(cfg))
+	assert.Equal(t, "9090", cfg.GetString("app.port"))
+}
+
+// TestRegistryEnvPrefix tests that keys without Env use the WithEnv prefix.
+func TestRegistryEnvPrefix(t *testing.T) {
+	os.Setenv("CONFIG_CACHE_SIZE", "64")
+	defer os.Unsetenv("CONFIG_CACHE_SIZE")
+
+	size := Key[int]{Path: "cache.size", Default: 16}
+	r := NewRegistry()
+	assert.NoError(t, r.Register(size))
+
+	viper.Reset()
+	cfg, err := New(WithEnv("CONFIG"), WithRegistry(r))
+	assert.NoError(t, err)
+	assert.Equal(t, 64, size.Get(cfg))
+}
+
+// TestRegistryValidation tests that New fails when a key is invalid.
+func TestRegistryValidation(t *testing.T) {
+	port := Key[int]{
+		Path:    "app.port",
+		Default: 8080,
+		Validate: func(p int) error {
+			if p < 1 || p > 65535 {
+				return errors.New("port out of range")
+			}
+			return nil
+		},
+	}
+	r := NewRegistry()
+	assert.NoError(t, r.Register(port))
+
+	_, err := New(WithDefault(map[string]interface{}{"app.port": 70000}), WithRegistry(r))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid value for app.port: port out of range")
+
+	_, err = New(WithDefault(map[string]interface{}{"app.port": "abc"}), WithRegistry(r))
+	assert.Error(t, err)
+
+	cfg, err := New(WithRegistry(r))
+	assert.NoError(t, err)
+	assert.Equal(t, 8080, port.Get(cfg))
+}
+
+// TestRegistryDuplicate tests duplicate and empty key registration.
+func TestRegistryDuplicate(t *testing.T) {
+	r := NewRegistry()
+	assert.NoError(t, r.Register(Key[int]{Path: "app.port"}))
+	assert.Error(t, r.Register(Key[string]{Path: "App.Port"}))
+	assert.Error(t, r.Register(Key[string]{}))
+	assert.Len(t, r.Keys(), 1)
+
+	// Register adds to DefaultRegistry, which is restored for other tests
+	defaultRegistry := DefaultRegistry
+	DefaultRegistry = NewRegistry()
+	t.Cleanup(func() { DefaultRegistry = defaultRegistry })
+	key := Register(Key[bool]{Path: "test.registry.enabled", Default: true})
+	cfg, err := New()
+	assert.NoError(t, err)
+	assert.True(t, key.Get(cfg))
+	assert.Panics(t, func() { Register(key) })
+}
+
+// TestRegistryDocs tests GenerateDocs with a registry.
+func TestRegistryDocs(t *testing.T) {
+	r := NewRegistry()
+	assert.NoError(t, r.Register(
+		Key[int]{Path: "app.port", Default: 8080, Env: "APP_PORT", Description: "Listen port."},
+		Key[string]{Path: "db.password", Default: "secret", Secret: true},
+		Key[[]string]{Path: "app.tags"},
+		Key[*int]{Path: "app.limit"},
+		Key[string]{Path: "api.token", Secret: true},
+	))
+	docs, err := GenerateDocs(r, DocFormatMarkdown, WithDocEnvPrefix("CONFIG"))
+	assert.NoError(t, err)
+	assert.Contains(t, docs, "| `app.port` | `APP_PORT` | `app-port` | `int` | 8080 | no | no | Listen port. |")
+	assert.Contains(t, docs, "| `db.password` | `CONFIG_DB_PASSWORD` | `db-password` | `string` | (redacted) | no | yes |  |")
+	assert.Contains(t, docs, "| `app.tags` | `CONFIG_APP_TAGS` | `app-tags` | `[]string` |  | no | no |  |")
+	assert.Contains(t, docs, "| `api.token` | `CONFIG_API_TOKEN` | `api-token` | `string` |  | no | yes |  |")
+	assert.Contains(t, docs, "| `app.limit` | `CONFIG_APP_LIMIT` | `app-limit` | `*int` |  | no | no |  |")
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrClosed is returned when changing a Config after Close.
+var ErrClosed = errors.New("config is closed")
+
+// NewContext creates a new Config like New, passing ctx to providers while they
+// load. Background work started by the Config, such as signal handlers and
+// provider watchers, stops when ctx is done or Close is called, whichever
+// happens first.
+func NewContext(ctx context.Context, opts ...Option) (*Config, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c, err := build(ctx, opts, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	c.ctx, c.cancel = context.WithCancel(ctx)
+	c.stopAfter = context.AfterFunc(ctx, func() {
+		_ = c.Close()
+	})
+	c.startWatchers()
+	return c, nil
+}
+
+// Close stops all background work, waits for it to finish, and drops every
+// subscription and bound value. Reads keep returning the last state, while
+// Set, Unset and Reload return ErrClosed. Close is safe to call more than
+// once but must not be called from a callback run by a background goroutine,
+// such as the onReload callback of ReloadOnSignal. Closing a view returns
+// ErrReadOnly.
+func (c *Config) Close() error {
+	if c.root != nil {
+		return ErrReadOnly
+	}
+	c.lifeMu.Lock()
+	if !c.closed {
+		c.closed = true
+		if c.stopAfter != nil {
+			c.stopAfter()
+		}
+		if c.cancel != nil {
+			c.cancel()
+		}
+	}
+	c.lifeMu.Unlock()
+	c.wg.Wait()
+
+	c.writeMu.Lock()
+	c.binders = nil
+	c.writeMu.Unlock()
+	c.notifyMu.Lock()
+	for _, s := range c.subs {
+		s.closed.Store(true)
+	}
+	c.subs = nil
+	c.pending = nil
+	c.notifyMu.Unlock()
+	return nil
+}
+
+// isClosed reports whether Close has been called on c.
+func (c *Config) isClosed() bool {
+	c.lifeMu.Lock()
+	defer c.lifeMu.Unlock()
+	return c.closed
+}
+
+// goBackground runs fn in a goroutine that Close waits for. fn must return
+// once ctx is done. It reports false, without running fn, if c is closed.
+func (c *Config) goBackground(fn func(ctx context.Context)) bool {
+	c.lifeMu.Lock()
+	defer c.lifeMu.Unlock()
+	if c.closed {
+		return false
+	}
+	if c.ctx == nil {
+		c.ctx, c.cancel = context.WithCancel(context.Background())
+	}
+	ctx := c.ctx
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		fn(ctx)
+	}()
+	return true
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/goleak"
+)
+
+// TestMain fails the package if any test leaves goroutines running.
+func TestMain(m *testing.M) {
+	goleak.VerifyTestMain(m)
+}
+
+// TestClose tests stopping background work and dropping subscriptions.
+func TestClose(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	for i := 0; i < 50; i++ {
+		cfg, err := New()
+		assert.NoError(t, err)
+		stop := cfg.ReloadOnSignal(nil)
+		defer stop()
+
+		calls := 0
+		sub := cfg.OnChange(func(ChangeEvent) {
+			calls++
+		})
+		assert.NoError(t, cfg.Set("debug", true))
+
+		assert.NoError(t, cfg.Close())
+		assert.NoError(t, cfg.Close())
+		assert.ErrorIs(t, cfg.Sub("app").Close(), ErrReadOnly)
+		assert.ErrorIs(t, cfg.Set("debug", false), ErrClosed)
+		assert.ErrorIs(t, cfg.Reload(context.Background()), ErrClosed)
+		assert.True(t, cfg.GetBool("debug"), "reads return the last state")
+		assert.Equal(t, 1, calls)
+		assert.True(t, sub.closed.Load())
+		assert.True(t, cfg.OnChange(func(ChangeEvent) {}).closed.Load())
+		cfg.ReloadOnSignal(nil)()
+	}
+}
+
+// TestNewContext tests that cancelling the context closes the Config.
+func TestNewContext(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := NewContext(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cfgs := make([]*Config, 50)
+	for i := range cfgs {
+		cfgs[i], err = NewContext(ctx)
+		assert.NoError(t, err)
+		cfgs[i].ReloadOnSignal(nil)
+	}
+	cancel()
+	for _, cfg := range cfgs {
+		assert.Eventually(t, cfg.isClosed, time.Second, time.Millisecond)
+		assert.NoError(t, cfg.Close())
+		assert.ErrorIs(t, cfg.Set("debug", true), ErrClosed)
+	}
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Redacted replaces the values of secret keys in diffs and docs.
+const Redacted = "(redacted)"
+
+// Source reports where the current value of key comes from: "override" for
+// values set with Set, "env:NAME" for environment variables, "provider:NAME"
+// for providers, "file:PATH" for configuration files and "default" for
+// defaults. It returns an empty string if the key is not set.
+func (c *Config) Source(key string) string {
+	r := c.base()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.sources[strings.ToLower(c.key(key))]
+}
+
+// resolveSources records the source of every key of c. It runs at the end of
+// build, so environment variables are inspected as they were at that time.
+func (c *Config) resolveSources() map[string]string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	envNames := make(map[string]string)
+	if c.registry != nil {
+		for _, k := range c.registry.Keys() {
+			if info := k.info(); info.Env != "" {
+				envNames[info.Path] = info.Env
+			}
+		}
+	}
+	sources := make(map[string]string)
+	for key := range leaves(c.v, "") {
+		sources[key] = c.sourceOf(key, envNames)
+	}
+	return sources
+}
+
+// sourceOf determines the source of a single key.
+func (c *Config) sourceOf(key string, envNames map[string]string) string {
+	for k := key; k != ""; k = parentKey(k) {
+		if c.isOverridden(k) {
+			return "override"
+		}
+	}
+	name, ok := envNames[key]
+	if !ok && c.envPrefix != "" {
+		name = envName(c.envPrefix, key)
+	}
+	if name != "" {
+		if _, ok := os.LookupEnv(name); ok {
+			return "env:" + name
+		}
+	}
+	if src, ok := c.providerSources[key]; ok {
+		return src
+	}
+	if c.v.InConfig(key) {
+		return "file:" + c.v.ConfigFileUsed()
+	}
+	return "default"
+}
+
+// secretKeys returns the keys of c that are marked as secret.
+func (c *Config) secretKeys() map[string]bool {
+	secrets := make(map[string]bool)
+	if c.registry != nil {
+		for _, k := range c.registry.Keys() {
+			if info := k.info(); info.Secret {
+				secrets[info.Path] = true
+			}
+		}
+	}
+	for key := range c.providerSecrets {
+		secrets[key] = true
+	}
+	return secrets
+}
+
+// isSecret reports whether key, or one of its parents, is in secrets.
+func isSecret(secrets map[string]bool, key string) bool {
+	for k := key; k != ""; k = parentKey(k) {
+		if secrets[k] {
+			return true
+		}
+	}
+	return false
+}
+
+// parentKey returns the parent of a dotted key, or "" for top-level keys.
+func parentKey(key string) string {
+	if i := strings.LastIndex(key, "."); i >= 0 {
+		return key[:i]
+	}
+	return ""
+}
+
+// leaves returns copies of the leaf values of v under prefix, indexed by
+// their dotted key relative to prefix.
+func leaves(v *viper.Viper, prefix string) map[string]interface{} {
+	if prefix == "" {
+		return flatten(cloneValue(v.AllSettings()).(map[string]interface{}))
+	}
+	m, _ := lookup(v, prefix).(map[string]interface{})
+	return flatten(m)
+}
@@ -0,0 +1,262 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Provider is a source of configuration values. Load returns a nested map of
+// values, like a decoded YAML document, and is called on New and on every
+// reload; Set and Unset reuse the data of the last call. Providers may
+// implement fmt.Stringer to name themselves in provenance, and Watcher to
+// trigger reloads. The name is read after every Load, so it may change, e.g.
+// to report the source that was used.
+type Provider interface {
+	Load(ctx context.Context) (map[string]any, error)
+}
+
+// Watcher is implemented by providers that can detect changes to their data.
+type Watcher interface {
+	// Watch returns a channel that receives an Event whenever the data
+	// returned by Load may have changed. The channel must be closed once ctx
+	// is done.
+	Watch(ctx context.Context) <-chan Event
+}
+
+// SecretProvider is implemented by providers that supply secrets. Values of
+// the keys it reports after Load are redacted in diffs and change events.
+type SecretProvider interface {
+	// SecretKeys returns the dotted config keys of the secret values
+	// returned by the last Load. Parent keys cover their whole subtree.
+	SecretKeys() []string
+}
+
+// Event is sent by a Watcher when its data changes or watching fails.
+type Event struct {
+	// Err is set if the provider failed to check for changes. No reload is
+	// triggered for such events.
+	Err error
+}
+
+// requestTimeout bounds the requests of providers whose HTTP client has no
+// timeout because it also serves long-lived watches.
+const requestTimeout = 30 * time.Second
+
+// providerResult is the outcome of a provider's last Load, reused by
+// changes that do not reload the sources.
+type providerResult struct {
+	data    map[string]interface{}
+	name    string
+	secrets []string
+}
+
+// providerEntry is a provider registered with WithProvider.
+type providerEntry struct {
+	p        Provider
+	priority int
+}
+
+// WithProvider adds p as a configuration source. Provider data is merged
+// after every option has been applied, in ascending order of priority, so it
+// takes precedence over files and defaults but not over environment variables
+// or values set with Set. Providers with equal priority are merged in the
+// order they were added. If p implements Watcher, it is watched until the
+// Config is closed.
+func WithProvider(p Provider, priority int) Option {
+	return func(c *Config) error {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.providers = append(c.providers, providerEntry{p: p, priority: priority})
+		return nil
+	}
+}
+
+// providerName returns the name of p used in provenance.
+func providerName(p Provider) string {
+	if s, ok := p.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("%T", p)
+}
+
+// applyProviders loads every provider of c and merges its data.
+func (c *Config) applyProviders(ctx context.Context) error {
+	if len(c.providers) == 0 {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sort.SliceStable(c.providers, func(i, j int) bool {
+		return c.providers[i].priority < c.providers[j].priority
+	})
+	// Providers are only loaded again on Reload; other changes reuse the
+	// data of the last load
+	if len(c.cache.providers) != len(c.providers) {
+		results := make([]providerResult, len(c.providers))
+		for i, e := range c.providers {
+			data, err := e.p.Load(ctx)
+			name := providerName(e.p)
+			if err != nil {
+				return fmt.Errorf("failed to load provider %s: %w", name, err)
+			}
+			results[i] = providerResult{data: cloneValue(data).(map[string]interface{}), name: name}
+			if sp, ok := e.p.(SecretProvider); ok {
+				results[i].secrets = sp.SecretKeys()
+			}
+		}
+		c.cache.providers = results
+	}
+	c.providerSources = make(map[string]string)
+	c.providerSecrets = make(map[string]bool)
+	for _, r := range c.cache.providers {
+		// Viper modifies merged maps in place
+		data, _ := cloneValue(r.data).(map[string]interface{})
+		if err := c.v.MergeConfigMap(data); err != nil {
+			return fmt.Errorf("failed to merge provider %s: %w", r.name, err)
+		}
+		for key := range flatten(data) {
+			c.providerSources[key] = "provider:" + r.name
+		}
+		for _, key := range r.secrets {
+			c.providerSecrets[strings.ToLower(key)] = true
+		}
+	}
+	if err := c.v.Unmarshal(&c.configStruct); err != nil {
+		return fmt.Errorf("failed to unmarshal ConfigStruct: %w", err)
+	}
+	return c.validateRequiredFields()
+}
+
+// startWatchers starts a goroutine for every provider of c that implements
+// Watcher. Each event triggers a Reload. The goroutine only returns once the
+// provider has closed its channel.
+func (c *Config) startWatchers() {
+	c.mu.RLock()
+	providers := c.providers
+	c.mu.RUnlock()
+	for _, e := range providers {
+		w, ok := e.p.(Watcher)
+		if !ok {
+			continue
+		}
+		c.goBackground(func(ctx context.Context) {
+			events := w.Watch(ctx)
+			for {
+				select {
+				case <-ctx.Done():
+					// Wait for the provider to stop, so that Close waits for
+					// its goroutines too
+					for range events {
+					}
+					return
+				case ev, ok := <-events:
+					if !ok {
+						return
+					}
+					if ev.Err != nil {
+						c.log().Warn("config provider watch failed", "provider", providerName(e.p), "error", ev.Err)
+						continue
+					}
+					if err := c.Reload(ctx); err != nil {
+						c.log().Error("config reload failed", "provider", providerName(e.p), "error", err)
+					} else {
+						c.log().Info("config reloaded", "provider", providerName(e.p))
+					}
+				}
+			}
+		})
+	}
+}
+
+// flatten returns the leaf values of a nested map indexed by their lower-case
+// dotted key.
+func flatten(m map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{})
+	var walk func(path string, node interface{})
+	walk = func(path string, node interface{}) {
+		if m, ok := node.(map[string]interface{}); ok && len(m) > 0 {
+			for k, child := range m {
+				walk(joinKey(path, strings.ToLower(k)), child)
+			}
+			return
+		}
+		if path != "" {
+			out[path] = node
+		}
+	}
+	walk("", m)
+	return out
+}
+
+// poll calls check every interval until ctx is done and sends an Event when
+// check reports a change or fails. After a failure, the next check is
+// delayed with exponential backoff and jitter, up to maxBackoff. The channel
+// is closed once ctx is done.
+func poll(ctx context.Context, interval, maxBackoff time.Duration, check func(ctx context.Context) (bool, error)) <-chan Event {
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		failures := 0
+		for {
+			delay := interval
+			if failures > 0 {
+				delay = backoff(interval, maxBackoff, failures)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+			changed, err := check(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				failures++
+			} else {
+				failures = 0
+			}
+			if (err != nil || changed) && !sendEvent(ctx, events, Event{Err: err}) {
+				return
+			}
+		}
+	}()
+	return events
+}
+
+// sendEvent sends e on events unless ctx is done first, and reports whether
+// it was sent.
+func sendEvent(ctx context.Context, events chan<- Event, e Event) bool {
+	select {
+	case events <- e:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// setPath stores value in m at the path given by keys, creating nested maps
+// as needed. A map value is merged with an existing map at the same path.
+func setPath(m map[string]any, keys []string, value any) {
+	for _, k := range keys[:len(keys)-1] {
+		child, ok := m[k].(map[string]any)
+		if !ok {
+			child = make(map[string]any)
+			m[k] = child
+		}
+		m = child
+	}
+	last := keys[len(keys)-1]
+	src, ok := value.(map[string]any)
+	dst, isMap := m[last].(map[string]any)
+	if !ok || !isMap {
+		m[last] = value
+		return
+	}
+	for k, v := range src {
+		setPath(dst, []string{k}, v)
+	}
+}
@@ -0,0 +1,208 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/goleak"
+)
+
+// testProvider is a Provider and Watcher with data controlled by the test.
+type testProvider struct {
+	name   string
+	mu     sync.Mutex
+	data   map[string]any
+	err    error
+	loads  int
+	events chan Event
+}
+
+func newTestProvider(name string, data map[string]any) *testProvider {
+	return &testProvider{name: name, data: data, events: make(chan Event)}
+}
+
+func (p *testProvider) String() string {
+	return p.name
+}
+
+func (p *testProvider) Load(ctx context.Context) (map[string]any, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.loads++
+	return p.data, p.err
+}
+
+func (p *testProvider) Watch(ctx context.Context) <-chan Event {
+	out := make(chan Event)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e := <-p.events:
+				select {
+				case out <- e:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out
+}
+
+func (p *testProvider) set(data map[string]any, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.data = data
+	p.err = err
+}
+
+// TestProvider tests merging provider data with the other sources.
+func TestProvider(t *testing.T) {
+	os.Setenv("CONFIG_APP_PORT", "9000")
+	defer os.Unsetenv("CONFIG_APP_PORT")
+
+	viper.Reset()
+	high := newTestProvider("high", map[string]any{
+		"app": map[string]any{"Name": "high-app"},
+	})
+	low := newTestProvider("low", map[string]any{
+		"app":   map[string]any{"name": "low-app", "port": 1},
+		"extra": "low",
+	})
+	cfg := newTestConfig(t, `
+environment: production
+app:
+  name: file-app
+  workers: 2
+`, WithProvider(high, 20), WithProvider(low, 10), WithEnv("CONFIG"))
+	defer cfg.Close()
+
+	assert.Equal(t, "high-app", cfg.GetString("app.name"))
+	assert.Equal(t, 9000, cfg.GetInt("app.port"))
+	assert.Equal(t, 2, cfg.GetInt("app.workers"))
+	assert.Equal(t, "provider:high", cfg.Source("app.name"))
+	assert.Equal(t, "provider:low", cfg.Source("extra"))
+	assert.Equal(t, "env:CONFIG_APP_PORT", cfg.Source("app.port"))
+	assert.Equal(t, "file:"+cfg.v.ConfigFileUsed(), cfg.Source("app.workers"))
+
+	assert.NoError(t, cfg.Set("app.name", "override-app"))
+	assert.Equal(t, "override-app", cfg.GetString("app.name"))
+	assert.Equal(t, map[string]any{"Name": "high-app"}, high.data["app"], "provider data is not modified")
+
+	high.set(map[string]any{"environment": "staging"}, nil)
+	assert.NoError(t, cfg.Reload(context.Background()))
+	assert.Equal(t, "staging", cfg.GetConfigStruct().Environment)
+	assert.Equal(t, "provider:high", cfg.Source("environment"))
+
+	high.set(nil, errors.New("unavailable"))
+	err := cfg.Reload(context.Background())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to load provider high")
+	assert.Equal(t, "staging", cfg.GetConfigStruct().Environment)
+}
+
+// TestProviderValidation tests that invalid provider data is rejected.
+func TestProviderValidation(t *testing.T) {
+	_, err := New(WithProvider(newTestProvider("bad", map[string]any{"environment": ""}), 0))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "required field Environment is not set")
+
+	p := newTestProvider("down", nil)
+	p.err = errors.New("connection refused")
+	_, err = New(WithProvider(p, 0))
+	assert.ErrorContains(t, err, "failed to load provider down: connection refused")
+}
+
+// TestProviderCache tests that only reloads load providers again.
+func TestProviderCache(t *testing.T) {
+	p := newTestProvider("remote", map[string]any{"environment": "production"})
+	cfg, err := New(WithProvider(p, 0))
+	assert.NoError(t, err)
+	defer cfg.Close()
+
+	p.set(nil, errors.New("connection refused"))
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, cfg.Set("debug", i%2 == 0))
+	}
+	assert.NoError(t, cfg.Unset("debug"))
+	assert.Equal(t, 1, p.loads)
+	assert.Equal(t, "production", cfg.GetConfigStruct().Environment)
+	assert.Equal(t, "provider:remote", cfg.Source("environment"))
+
+	assert.ErrorContains(t, cfg.Reload(context.Background()), "connection refused")
+	p.set(map[string]any{"environment": "staging"}, nil)
+	assert.NoError(t, cfg.Reload(context.Background()))
+	assert.NoError(t, cfg.Set("debug", true))
+	assert.Equal(t, 3, p.loads)
+	assert.Equal(t, "staging", cfg.GetConfigStruct().Environment)
+}
+
+// TestProviderWatch tests reloads triggered by a Watcher.
+func TestProviderWatch(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	p := newTestProvider("watched", map[string]any{"app": map[string]any{"name": "v1"}})
+	cfg, err := NewContext(context.Background(), WithProvider(p, 0), WithLogger(slog.New(slog.DiscardHandler)))
+	assert.NoError(t, err)
+	defer cfg.Close()
+
+	events := make(chan ChangeEvent, 1)
+	cfg.OnChange(func(e ChangeEvent) {
+		events <- e
+	})
+
+	p.events <- Event{Err: errors.New("watch interrupted")}
+	p.set(map[string]any{"app": map[string]any{"name": "v2"}}, nil)
+	p.events <- Event{}
+	select {
+	case e := <-events:
+		assert.Equal(t, ChangeReload, e.Source)
+		assert.Equal(t, []Change{{
+			Key: "app.name", Kind: ChangeModified,
+			Old: "v1", New: "v2",
+			OldSource: "provider:watched", NewSource: "provider:watched",
+		}}, e.Changes)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for reload")
+	}
+	assert.Equal(t, "v2", cfg.GetString("app.name"))
+	assert.NoError(t, cfg.Close())
+}
+
+// slowWatcher is a testProvider whose watch takes a while to stop.
+type slowWatcher struct {
+	*testProvider
+	stopped atomic.Bool
+}
+
+func (p *slowWatcher) Watch(ctx context.Context) <-chan Event {
+	out := make(chan Event)
+	go func() {
+		defer close(out)
+		<-ctx.Done()
+		time.Sleep(50 * time.Millisecond)
+		p.stopped.Store(true)
+	}()
+	return out
+}
+
+// TestProviderWatchClose tests that Close waits for watching providers to
+// stop.
+func TestProviderWatchClose(t *testing.T) {
+	p := &slowWatcher{testProvider: newTestProvider("slow", nil)}
+	cfg, err := NewContext(context.Background(), WithProvider(p, 0))
+	assert.NoError(t, err)
+	assert.NoError(t, cfg.Close())
+	assert.True(t, p.stopped.Load())
+}
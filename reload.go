@@ -0,0 +1,95 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// WithLogger sets the logger used to report background activity such as
+// signal-triggered reloads. The default is slog.Default().
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Config) error {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.logger = logger
+		return nil
+	}
+}
+
+// log returns the logger of c.
+func (c *Config) log() *slog.Logger {
+	r := c.base()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.logger == nil {
+		return slog.Default()
+	}
+	return r.logger
+}
+
+// Reload re-runs every option passed to New, re-reading files, providers and
+// environment variables, and re-applies runtime overrides. The result goes
+// through the same validation as Set: on failure, or if ctx is done before the
+// new state is committed, the current state is kept and the error is returned.
+// Views reload their root configuration.
+func (c *Config) Reload(ctx context.Context) error {
+	r := c.base()
+	if err := r.update(ctx, ChangeEvent{Source: ChangeReload}, nil); err != nil {
+		return fmt.Errorf("failed to reload config: %w", err)
+	}
+	return nil
+}
+
+// ReloadOnSignal calls Reload whenever the process receives one of sigs,
+// which defaults to SIGHUP, following the Unix daemon convention. The outcome
+// of every reload is logged and passed to onReload, which may be nil. Signals
+// are handled until the returned function is called or the Config is closed.
+// stop waits for an in-flight reload to finish and must not be called from
+// onReload.
+func (c *Config) ReloadOnSignal(onReload func(error), sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	stopped := make(chan struct{})
+	done := make(chan struct{})
+	started := c.base().goBackground(func(ctx context.Context) {
+		defer close(done)
+		defer signal.Stop(ch)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-stopped:
+				return
+			case sig := <-ch:
+				err := c.Reload(ctx)
+				if err != nil {
+					c.log().Error("config reload failed", "signal", sig.String(), "error", err)
+				} else {
+					c.log().Info("config reloaded", "signal", sig.String())
+				}
+				if onReload != nil {
+					onReload(err)
+				}
+			}
+		}
+	})
+	if !started {
+		signal.Stop(ch)
+		return func() {}
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(stopped)
+		})
+		<-done
+	}
+}
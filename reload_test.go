@@ -0,0 +1,69 @@
+package config
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeFile replaces the content of a test config file.
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+// TestReload tests reloading changed configuration files.
+func TestReload(t *testing.T) {
+	cfg := newTestConfig(t, `
+environment: production
+app:
+  port: 8080
+`)
+	path := cfg.v.ConfigFileUsed()
+	assert.NoError(t, cfg.Set("debug", true))
+
+	var events []ChangeEvent
+	cfg.OnChange(func(e ChangeEvent) {
+		events = append(events, e)
+	})
+	db := cfg.Sub("app")
+
+	writeFile(t, path, `
+environment: staging
+app:
+  port: 9090
+`)
+	assert.NoError(t, cfg.Reload(context.Background()))
+	assert.Equal(t, "staging", cfg.GetConfigStruct().Environment)
+	assert.Equal(t, 9090, db.GetInt("port"))
+	assert.True(t, cfg.GetBool("debug"), "overrides survive reloads")
+	assert.Len(t, events, 1)
+	assert.Equal(t, ChangeReload, events[0].Source)
+	assert.Len(t, events[0].Changes, 2)
+
+	assert.NoError(t, db.Reload(context.Background()))
+	assert.Len(t, events, 2)
+	assert.Empty(t, events[1].Changes)
+}
+
+// TestReloadFailureKeepsState tests that failed reloads are not applied.
+func TestReloadFailureKeepsState(t *testing.T) {
+	cfg := newTestConfig(t, `
+environment: production
+`)
+	path := cfg.v.ConfigFileUsed()
+
+	writeFile(t, path, `environment: [unclosed`)
+	err := cfg.Reload(context.Background())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to reload config")
+	assert.Equal(t, "production", cfg.GetConfigStruct().Environment)
+
+	writeFile(t, path, `environment: staging`)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, cfg.Reload(ctx), context.Canceled)
+	assert.Equal(t, "production", cfg.GetConfigStruct().Environment)
+}
@@ -0,0 +1,54 @@
+//go:build unix
+
+package config
+
+import (
+	"bytes"
+	"log/slog"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestReloadOnSignal tests signal-triggered reloads.
+func TestReloadOnSignal(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	cfg := newTestConfig(t, `
+environment: production
+`, WithLogger(logger))
+	path := cfg.v.ConfigFileUsed()
+
+	results := make(chan error, 1)
+	stop := cfg.ReloadOnSignal(func(err error) {
+		results <- err
+	}, syscall.SIGUSR1)
+	defer stop()
+
+	writeFile(t, path, `environment: staging`)
+	assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR1))
+	select {
+	case err := <-results:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for reload")
+	}
+	assert.Equal(t, "staging", cfg.GetConfigStruct().Environment)
+
+	writeFile(t, path, `environment: [unclosed`)
+	assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR1))
+	select {
+	case err := <-results:
+		assert.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for reload")
+	}
+	assert.Equal(t, "staging", cfg.GetConfigStruct().Environment)
+
+	stop()
+	stop()
+	assert.Contains(t, logs.String(), "config reloaded")
+	assert.Contains(t, logs.String(), "config reload failed")
+}
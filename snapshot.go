@@ -0,0 +1,119 @@
+package config
+
+import (
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+)
+
+// Snapshot is an immutable, flattened view of the configuration at a point in
+// time. Reads take no locks and scalar reads of lower-case keys do not
+// allocate, which makes it suitable for hot paths. Obtain a fresh Snapshot with
+// Config.Snapshot after a change to observe new values.
+type Snapshot struct {
+	values map[string]interface{}
+	prefix string
+}
+
+// newSnapshot flattens the merged settings of v into a Snapshot. Both leaf
+// values and subtrees are indexed by their dotted key.
+func newSnapshot(v *viper.Viper) *Snapshot {
+	s := &Snapshot{values: make(map[string]interface{})}
+	s.flatten("", v.AllSettings())
+	return s
+}
+
+// flatten indexes every value of m under prefix.
+func (s *Snapshot) flatten(prefix string, m map[string]interface{}) {
+	for k, val := range m {
+		key := joinKey(prefix, k)
+		s.values[key] = val
+		if sub, ok := val.(map[string]interface{}); ok {
+			s.flatten(key, sub)
+		}
+	}
+}
+
+// Snapshot returns the current immutable snapshot. It is published atomically
+// on every change, so the call itself takes no locks. Snapshots of views
+// created with Sub qualify keys with the view prefix on each read.
+func (c *Config) Snapshot() *Snapshot {
+	r := c.base()
+	s := r.snapshot.Load()
+	if c.prefix == "" {
+		return s
+	}
+	return &Snapshot{values: s.values, prefix: c.prefix}
+}
+
+// lookup returns the raw value at key.
+func (s *Snapshot) lookup(key string) (interface{}, bool) {
+	if s.prefix != "" {
+		key = s.prefix + "." + key
+	}
+	val, ok := s.values[key]
+	if !ok {
+		val, ok = s.values[strings.ToLower(key)]
+	}
+	return val, ok
+}
+
+// IsSet reports whether key has a value in the snapshot.
+func (s *Snapshot) IsSet(key string) bool {
+	_, ok := s.lookup(key)
+	return ok
+}
+
+// Get retrieves a raw value by key. Maps and slices are returned as copies, so
+// the snapshot stays immutable.
+func (s *Snapshot) Get(key string) interface{} {
+	val, _ := s.lookup(key)
+	return cloneValue(val)
+}
+
+// GetString retrieves a string value.
+func (s *Snapshot) GetString(key string) string {
+	val, _ := s.lookup(key)
+	if str, ok := val.(string); ok {
+		return str
+	}
+	return cast.ToString(val)
+}
+
+// GetBool retrieves a boolean value.
+func (s *Snapshot) GetBool(key string) bool {
+	val, _ := s.lookup(key)
+	if b, ok := val.(bool); ok {
+		return b
+	}
+	return cast.ToBool(val)
+}
+
+// GetInt retrieves an int value.
+func (s *Snapshot) GetInt(key string) int {
+	val, _ := s.lookup(key)
+	if i, ok := val.(int); ok {
+		return i
+	}
+	return cast.ToInt(val)
+}
+
+// GetInt64 retrieves an int64 value.
+func (s *Snapshot) GetInt64(key string) int64 {
+	val, _ := s.lookup(key)
+	return cast.ToInt64(val)
+}
+
+// GetFloat64 retrieves a float64 value.
+func (s *Snapshot) GetFloat64(key string) float64 {
+	val, _ := s.lookup(key)
+	return cast.ToFloat64(val)
+}
+
+// GetDuration retrieves a time.Duration value.
+func (s *Snapshot) GetDuration(key string) time.Duration {
+	val, _ := s.lookup(key)
+	return cast.ToDuration(val)
+}
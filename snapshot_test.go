@@ -0,0 +1,103 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const snapshotTestYAML = `
+environment: production
+debug: true
+app:
+  name: my-app
+  port: 8080
+  ratio: 0.5
+  timeout: 30s
+`
+
+// TestSnapshot tests reading values from a snapshot.
+func TestSnapshot(t *testing.T) {
+	cfg := newTestConfig(t, snapshotTestYAML)
+	s := cfg.Snapshot()
+	assert.Equal(t, "production", s.GetString("environment"))
+	assert.True(t, s.GetBool("debug"))
+	assert.Equal(t, "my-app", s.GetString("App.Name"))
+	assert.Equal(t, 8080, s.GetInt("app.port"))
+	assert.Equal(t, int64(8080), s.GetInt64("app.port"))
+	assert.Equal(t, "8080", s.GetString("app.port"))
+	assert.Equal(t, 0.5, s.GetFloat64("app.ratio"))
+	assert.Equal(t, 30*time.Second, s.GetDuration("app.timeout"))
+	assert.True(t, s.IsSet("app"))
+	assert.False(t, s.IsSet("missing"))
+	assert.Nil(t, s.Get("missing"))
+
+	app := cfg.Sub("app").Snapshot()
+	assert.Equal(t, "my-app", app.GetString("name"))
+	assert.False(t, app.IsSet("environment"))
+}
+
+// TestSnapshotIsImmutable tests that snapshots do not observe later changes.
+func TestSnapshotIsImmutable(t *testing.T) {
+	cfg := newTestConfig(t, snapshotTestYAML)
+	before := cfg.Snapshot()
+	assert.NoError(t, cfg.Set("app.port", 9090))
+	after := cfg.Snapshot()
+	assert.Equal(t, 8080, before.GetInt("app.port"))
+	assert.Equal(t, 9090, after.GetInt("app.port"))
+	assert.Same(t, after, cfg.Snapshot())
+}
+
+// TestSnapshotZeroAllocs tests that snapshot reads do not allocate.
+func TestSnapshotZeroAllocs(t *testing.T) {
+	cfg := newTestConfig(t, snapshotTestYAML)
+	s := cfg.Snapshot()
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = cfg.Snapshot().GetString("app.name")
+		_ = s.GetInt("app.port")
+		_ = s.GetBool("debug")
+		_ = s.GetDuration("app.timeout")
+	})
+	assert.Zero(t, allocs)
+}
+
+// BenchmarkGetString measures GetString through viper.
+func BenchmarkGetString(b *testing.B) {
+	cfg := newTestConfig(b, snapshotTestYAML)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = cfg.GetString("app.name")
+	}
+}
+
+// BenchmarkSnapshotGetString measures GetString through a snapshot.
+func BenchmarkSnapshotGetString(b *testing.B) {
+	cfg := newTestConfig(b, snapshotTestYAML)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = cfg.Snapshot().GetString("app.name")
+	}
+}
+
+// BenchmarkGetIntParallel measures concurrent GetInt through viper.
+func BenchmarkGetIntParallel(b *testing.B) {
+	cfg := newTestConfig(b, snapshotTestYAML)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = cfg.GetInt("app.port")
+		}
+	})
+}
+
+// BenchmarkSnapshotGetIntParallel measures concurrent GetInt through a snapshot.
+func BenchmarkSnapshotGetIntParallel(b *testing.B) {
+	cfg := newTestConfig(b, snapshotTestYAML)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = cfg.Snapshot().GetInt("app.port")
+		}
+	})
+}
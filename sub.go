@@ -0,0 +1,25 @@
+package config
+
+// Sub returns a read-only view of the configuration rooted at prefix. Keys
+// passed to the view are relative to prefix, and Unmarshal decodes only the
+// subtree. The view shares the lock and state of its parent, so it always
+// reflects the parent's current values. The prefix does not need to exist.
+func (c *Config) Sub(prefix string) *Config {
+	return &Config{
+		root:   c.base(),
+		prefix: c.key(prefix),
+	}
+}
+
+// base returns the Config that owns the shared state of c.
+func (c *Config) base() *Config {
+	if c.root != nil {
+		return c.root
+	}
+	return c
+}
+
+// key qualifies key with the view prefix of c.
+func (c *Config) key(key string) string {
+	return joinKey(c.prefix, key)
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSub tests reading values through a sub-configuration view.
+func TestSub(t *testing.T) {
+	cfg := newTestConfig(t, `
+environment: production
+database:
+  host: db.local
+  port: 5432
+  pool:
+    size: 10
+`)
+	db := cfg.Sub("database")
+	assert.Equal(t, "db.local", db.GetStringWithDefault("host", "localhost"))
+	assert.Equal(t, 5432, db.GetInt("port"))
+	assert.Equal(t, 10, ValueOr(db, "pool.size", 0))
+	assert.Nil(t, db.Get("environment"))
+	assert.Equal(t, "production", db.GetConfigStruct().Environment)
+
+	pool := db.Sub("pool")
+	assert.Equal(t, 10, pool.GetInt("size"))
+
+	type DatabaseConfig struct {
+		Host string `mapstructure:"host"`
+		Port int    `mapstructure:"port"`
+		Pool struct {
+			Size int `mapstructure:"size"`
+		} `mapstructure:"pool"`
+	}
+	var dbCfg DatabaseConfig
+	assert.NoError(t, db.Unmarshal(&dbCfg))
+	assert.Equal(t, "db.local", dbCfg.Host)
+	assert.Equal(t, 5432, dbCfg.Port)
+	assert.Equal(t, 10, dbCfg.Pool.Size)
+}
+
+// TestSubMissingPrefix tests a view rooted at a key that does not exist.
+func TestSubMissingPrefix(t *testing.T) {
+	cfg, err := New()
+	assert.NoError(t, err)
+	view := cfg.Sub("missing")
+	assert.NotNil(t, view)
+	assert.Nil(t, view.Get("key"))
+	_, err = view.GetStringE("key")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	var target struct {
+		Key string `mapstructure:"key"`
+	}
+	assert.NoError(t, view.Unmarshal(&target))
+	assert.Empty(t, target.Key)
+}
+
+// TestSubConcurrentAccess tests that views share the parent's lock.
+func TestSubConcurrentAccess(t *testing.T) {
+	cfg := newTestConfig(t, `
+database:
+  host: db.local
+`)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			db := cfg.Sub("database")
+			for j := 0; j < 100; j++ {
+				_ = db.GetStringWithDefault("host", "")
+				_ = cfg.Get("database.host")
+			}
+		}()
+	}
+	wg.Wait()
+}
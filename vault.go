@@ -0,0 +1,376 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errVaultForbidden is returned for 403 responses, which Vault also sends
+// for expired tokens.
+var errVaultForbidden = errors.New("permission denied")
+
+// VaultProvider loads a secret from a Vault KV version 2 engine. Every value
+// it returns is reported as secret. Authentication uses a token or AppRole.
+// As a Watcher it renews the token before its lease expires, logging in
+// again with AppRole when renewal is no longer possible, and reads the
+// secret again periodically and before its lease expires, so a rotated
+// secret triggers a reload.
+type VaultProvider struct {
+	addr     string
+	mount    string
+	path     string
+	key      string
+	client   *http.Client
+	token    string
+	roleID   string
+	secretID string
+	refresh  time.Duration
+
+	mu    sync.Mutex
+	auth  vaultToken
+	lease time.Duration
+	read  time.Time
+	data  map[string]any
+}
+
+// vaultToken is the current token and its lease.
+type vaultToken struct {
+	token     string
+	ttl       time.Duration
+	renewable bool
+	issued    time.Time
+}
+
+// VaultOption configures a VaultProvider.
+type VaultOption func(*VaultProvider)
+
+// WithVaultToken authenticates with token. If the token has a TTL and is
+// renewable, it is renewed before it expires.
+func WithVaultToken(token string) VaultOption {
+	return func(p *VaultProvider) {
+		p.token = token
+	}
+}
+
+// WithVaultAppRole authenticates with the AppRole auth method mounted at
+// "approle".
+func WithVaultAppRole(roleID, secretID string) VaultOption {
+	return func(p *VaultProvider) {
+		p.roleID = roleID
+		p.secretID = secretID
+	}
+}
+
+// WithVaultKey places the secret's fields under key, e.g. "db" for
+// "db.password". By default they are placed at the root.
+func WithVaultKey(key string) VaultOption {
+	return func(p *VaultProvider) {
+		p.key = strings.ToLower(key)
+	}
+}
+
+// WithVaultRefreshInterval sets how often the secret is read to detect
+// rotation. The default is 5 minutes.
+func WithVaultRefreshInterval(d time.Duration) VaultOption {
+	return func(p *VaultProvider) {
+		p.refresh = d
+	}
+}
+
+// WithVaultClient sets the HTTP client used for requests.
+func WithVaultClient(client *http.Client) VaultOption {
+	return func(p *VaultProvider) {
+		p.client = client
+	}
+}
+
+// NewVaultProvider returns a provider that reads the secret at path from the
+// KV version 2 engine mounted at mount on the Vault server at addr, e.g.
+// NewVaultProvider("https://vault:8200", "secret", "billing/db").
+func NewVaultProvider(addr, mount, path string, opts ...VaultOption) *VaultProvider {
+	p := &VaultProvider{
+		addr:    strings.TrimSuffix(addr, "/"),
+		mount:   strings.Trim(mount, "/"),
+		path:    strings.Trim(path, "/"),
+		client:  &http.Client{Timeout: 30 * time.Second},
+		refresh: 5 * time.Minute,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// String returns the secret as "vault:MOUNT/PATH".
+func (p *VaultProvider) String() string {
+	return "vault:" + p.mount + "/" + p.path
+}
+
+// Load reads the secret.
+func (p *VaultProvider) Load(ctx context.Context) (map[string]any, error) {
+	data, _, err := p.readSecret(ctx)
+	return data, err
+}
+
+// SecretKeys reports every key of the secret.
+func (p *VaultProvider) SecretKeys() []string {
+	if p.key != "" {
+		return []string{p.key}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	keys := make([]string, 0, len(p.data))
+	for k := range p.data {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Watch renews the token and reads the secret again when it is due, sending
+// an Event when the secret changes or either step fails.
+func (p *VaultProvider) Watch(ctx context.Context) <-chan Event {
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		failures := 0
+		for {
+			renewAt, readAt := p.schedule()
+			wake := readAt
+			if !renewAt.IsZero() && renewAt.Before(wake) {
+				wake = renewAt
+			}
+			wait := time.Until(wake)
+			if failures > 0 {
+				wait = backoff(time.Second, p.refresh, failures)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(wait):
+			}
+			var err error
+			if !renewAt.IsZero() && !time.Now().Before(renewAt) {
+				err = p.renewToken(ctx)
+			}
+			changed := false
+			if err == nil && !time.Now().Before(readAt) {
+				_, changed, err = p.readSecret(ctx)
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				failures++
+			} else {
+				failures = 0
+			}
+			if (err != nil || changed) && !sendEvent(ctx, events, Event{Err: err}) {
+				return
+			}
+		}
+	}()
+	return events
+}
+
+// schedule returns when the token must be renewed, or zero if it does not
+// expire, and when the secret must be read again. Both happen after two
+// thirds of their lease.
+func (p *VaultProvider) schedule() (renewAt, readAt time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.auth.ttl > 0 {
+		renewAt = p.auth.issued.Add(p.auth.ttl * 2 / 3)
+	}
+	interval := p.refresh
+	if p.lease > 0 {
+		interval = min(interval, p.lease*2/3)
+	}
+	return renewAt, p.read.Add(interval)
+}
+
+// readSecret reads the secret and reports whether its data changed.
+func (p *VaultProvider) readSecret(ctx context.Context) (map[string]any, bool, error) {
+	var out struct {
+		LeaseDuration int `json:"lease_duration"`
+		Data          struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	path := "/v1/" + p.mount + "/data/" + p.path
+	err := p.do(ctx, http.MethodGet, path, nil, &out)
+	if errors.Is(err, errVaultForbidden) && p.roleID != "" {
+		// The token may have expired early, e.g. after a revocation
+		if err = p.login(ctx); err == nil {
+			err = p.do(ctx, http.MethodGet, path, nil, &out)
+		}
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read %s: %w", p, err)
+	}
+	secret := make(map[string]any, len(out.Data.Data))
+	for k, v := range out.Data.Data {
+		secret[strings.ToLower(k)] = v
+	}
+	data := secret
+	if p.key != "" {
+		data = make(map[string]any)
+		setPath(data, strings.Split(p.key, "."), secret)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	changed := p.data == nil || !reflect.DeepEqual(p.data, secret)
+	p.data = secret
+	p.lease = time.Duration(out.LeaseDuration) * time.Second
+	p.read = time.Now()
+	return data, changed, nil
+}
+
+// renewToken renews the token, or logs in again with AppRole if the token
+// cannot be renewed.
+func (p *VaultProvider) renewToken(ctx context.Context) error {
+	p.mu.Lock()
+	renewable := p.auth.renewable
+	p.mu.Unlock()
+	if renewable {
+		var out vaultAuthResponse
+		err := p.do(ctx, http.MethodPost, "/v1/auth/token/renew-self", struct{}{}, &out)
+		// Renewal yields no lease once the token reaches its max TTL
+		if err == nil && out.Auth.LeaseDuration > 0 {
+			p.setToken(out)
+			return nil
+		}
+		if p.roleID == "" {
+			if err == nil {
+				err = errors.New("token reached its max TTL")
+			}
+			return fmt.Errorf("failed to renew vault token: %w", err)
+		}
+	}
+	if p.roleID != "" {
+		return p.login(ctx)
+	}
+	// A static token that cannot be renewed is used until it expires
+	p.mu.Lock()
+	p.auth.ttl = 0
+	p.mu.Unlock()
+	return nil
+}
+
+// vaultAuthResponse is the response of login and renewal requests.
+type vaultAuthResponse struct {
+	Auth struct {
+		ClientToken   string `json:"client_token"`
+		LeaseDuration int    `json:"lease_duration"`
+		Renewable     bool   `json:"renewable"`
+	} `json:"auth"`
+}
+
+// setToken stores the token from an auth response.
+func (p *VaultProvider) setToken(out vaultAuthResponse) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if out.Auth.ClientToken != "" {
+		p.auth.token = out.Auth.ClientToken
+	}
+	p.auth.ttl = time.Duration(out.Auth.LeaseDuration) * time.Second
+	p.auth.renewable = out.Auth.Renewable
+	p.auth.issued = time.Now()
+}
+
+// login obtains a token with AppRole, or looks up the lease of the static
+// token.
+func (p *VaultProvider) login(ctx context.Context) error {
+	if p.roleID == "" {
+		if p.token == "" {
+			return errors.New("no vault token or approle configured")
+		}
+		p.mu.Lock()
+		p.auth = vaultToken{token: p.token, issued: time.Now()}
+		p.mu.Unlock()
+		var out struct {
+			Data struct {
+				TTL       int  `json:"ttl"`
+				Renewable bool `json:"renewable"`
+			} `json:"data"`
+		}
+		// Tokens without permission to look themselves up are used as is
+		if err := p.do(ctx, http.MethodGet, "/v1/auth/token/lookup-self", nil, &out); err == nil {
+			p.mu.Lock()
+			p.auth.ttl = time.Duration(out.Data.TTL) * time.Second
+			p.auth.renewable = out.Data.Renewable
+			p.mu.Unlock()
+		}
+		return nil
+	}
+	p.mu.Lock()
+	p.auth = vaultToken{}
+	p.mu.Unlock()
+	var out vaultAuthResponse
+	in := map[string]string{"role_id": p.roleID, "secret_id": p.secretID}
+	if err := p.do(ctx, http.MethodPost, "/v1/auth/approle/login", in, &out); err != nil {
+		return fmt.Errorf("failed to log in to vault with approle: %w", err)
+	}
+	p.setToken(out)
+	return nil
+}
+
+// do sends a request to Vault and decodes the JSON response into out. It
+// logs in first if there is no token yet.
+func (p *VaultProvider) do(ctx context.Context, method, path string, in, out any) error {
+	p.mu.Lock()
+	token := p.auth.token
+	p.mu.Unlock()
+	isLogin := path == "/v1/auth/approle/login"
+	if token == "" && !isLogin {
+		if err := p.login(ctx); err != nil {
+			return err
+		}
+		p.mu.Lock()
+		token = p.auth.token
+		p.mu.Unlock()
+	}
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, p.addr+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if !isLogin {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusForbidden:
+		return errVaultForbidden
+	case resp.StatusCode != http.StatusOK:
+		var e struct {
+			Errors []string `json:"errors"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&e)
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, strings.Join(e.Errors, "; "))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
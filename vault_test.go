@@ -0,0 +1,174 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/goleak"
+)
+
+// testVault is a stand-in for the Vault HTTP API with a KV version 2 engine
+// mounted at "secret", AppRole login and token renewal.
+type testVault struct {
+	mu       sync.Mutex
+	secrets  map[string]map[string]any
+	tokens   map[string]bool
+	ttl      int
+	logins   int
+	renewals int
+	// maxRenewals makes renewals fail once reached, as at a token's max TTL
+	maxRenewals int
+}
+
+func newTestVault() *testVault {
+	return &testVault{
+		secrets:     make(map[string]map[string]any),
+		tokens:      map[string]bool{"static-token": true},
+		maxRenewals: -1,
+	}
+}
+
+func (s *testVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	reply := func(v any) {
+		_ = json.NewEncoder(w).Encode(v)
+	}
+	if r.URL.Path == "/v1/auth/approle/login" {
+		var in map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&in)
+		if in["role_id"] != "billing" || in["secret_id"] != "s3cret" {
+			w.WriteHeader(http.StatusBadRequest)
+			reply(map[string]any{"errors": []string{"invalid role or secret ID"}})
+			return
+		}
+		s.logins++
+		token := fmt.Sprintf("approle-token-%d", s.logins)
+		s.tokens[token] = true
+		reply(map[string]any{"auth": map[string]any{"client_token": token, "lease_duration": s.ttl, "renewable": true}})
+		return
+	}
+	token := r.Header.Get("X-Vault-Token")
+	if !s.tokens[token] {
+		w.WriteHeader(http.StatusForbidden)
+		reply(map[string]any{"errors": []string{"permission denied"}})
+		return
+	}
+	path, isSecret := strings.CutPrefix(r.URL.Path, "/v1/secret/data/")
+	switch {
+	case r.URL.Path == "/v1/auth/token/lookup-self":
+		reply(map[string]any{"data": map[string]any{"ttl": 0, "renewable": false}})
+	case r.URL.Path == "/v1/auth/token/renew-self":
+		if s.renewals == s.maxRenewals {
+			delete(s.tokens, token)
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		s.renewals++
+		reply(map[string]any{"auth": map[string]any{"client_token": token, "lease_duration": s.ttl, "renewable": true}})
+	case isSecret:
+		data, ok := s.secrets[path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			reply(map[string]any{"errors": []string{}})
+			return
+		}
+		reply(map[string]any{"lease_duration": 0, "data": map[string]any{"data": data, "metadata": map[string]any{"version": 1}}})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (s *testVault) setSecret(path string, data map[string]any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.secrets[path] = data
+}
+
+// TestVaultProvider tests reading KV version 2 secrets.
+func TestVaultProvider(t *testing.T) {
+	vault := newTestVault()
+	vault.setSecret("billing/db", map[string]any{"Password": "hunter2", "user": "billing"})
+	srv := httptest.NewServer(vault)
+	defer srv.Close()
+
+	p := NewVaultProvider(srv.URL, "secret", "billing/db", WithVaultToken("static-token"))
+	assert.Equal(t, "vault:secret/billing/db", p.String())
+	data, err := p.Load(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"password": "hunter2", "user": "billing"}, data)
+	assert.ElementsMatch(t, []string{"password", "user"}, p.SecretKeys())
+
+	p = NewVaultProvider(srv.URL, "secret", "billing/db", WithVaultAppRole("billing", "s3cret"), WithVaultKey("DB"))
+	data, err = p.Load(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"db": map[string]any{"password": "hunter2", "user": "billing"}}, data)
+	assert.Equal(t, []string{"db"}, p.SecretKeys())
+
+	_, err = NewVaultProvider(srv.URL, "secret", "billing/db", WithVaultToken("wrong")).Load(context.Background())
+	assert.ErrorContains(t, err, "failed to read vault:secret/billing/db: permission denied")
+	_, err = NewVaultProvider(srv.URL, "secret", "billing/db", WithVaultAppRole("billing", "wrong")).Load(context.Background())
+	assert.ErrorContains(t, err, "invalid role or secret ID")
+	_, err = NewVaultProvider(srv.URL, "secret", "missing", WithVaultToken("static-token")).Load(context.Background())
+	assert.ErrorContains(t, err, "404 Not Found")
+	_, err = NewVaultProvider(srv.URL, "secret", "billing/db").Load(context.Background())
+	assert.ErrorContains(t, err, "no vault token or approle configured")
+}
+
+// TestVaultProviderRotation tests token renewal and secret rotation.
+func TestVaultProviderRotation(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	vault := newTestVault()
+	vault.ttl = 1
+	vault.maxRenewals = 1
+	vault.setSecret("billing/db", map[string]any{"password": "hunter2"})
+	srv := httptest.NewServer(vault)
+	defer srv.Close()
+
+	p := NewVaultProvider(srv.URL, "secret", "billing/db",
+		WithVaultAppRole("billing", "s3cret"),
+		WithVaultKey("db"),
+		WithVaultRefreshInterval(50*time.Millisecond),
+	)
+	cfg, err := NewContext(context.Background(), WithProvider(p, 30), WithLogger(slog.New(slog.DiscardHandler)))
+	assert.NoError(t, err)
+	defer cfg.Close()
+	assert.Equal(t, "hunter2", cfg.GetString("db.password"))
+
+	events := make(chan ChangeEvent, 10)
+	cfg.OnChange(func(e ChangeEvent) {
+		events <- e
+	})
+	vault.setSecret("billing/db", map[string]any{"password": "correct-horse"})
+	select {
+	case e := <-events:
+		assert.Equal(t, []Change{{
+			Key: "db.password", Kind: ChangeModified,
+			Old: Redacted, New: Redacted,
+			OldSource: "provider:vault:secret/billing/db", NewSource: "provider:vault:secret/billing/db",
+		}}, e.Changes)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for rotation")
+	}
+	assert.Equal(t, "correct-horse", cfg.GetString("db.password"))
+
+	// The token is renewed once, then replaced by a new login when renewal
+	// fails at its max TTL
+	assert.Eventually(t, func() bool {
+		vault.mu.Lock()
+		defer vault.mu.Unlock()
+		return vault.renewals == 1 && vault.logins == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.NoError(t, cfg.Close())
+	assert.Empty(t, events, "renewals do not trigger reloads")
+}
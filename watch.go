@@ -0,0 +1,39 @@
+package config
+
+import (
+	"reflect"
+)
+
+// Watch registers fn to be called when the resolved value at key, or any
+// value under it, changes. fn receives the old and new values, which are nil
+// when the key is unset. Watchers are delivered together with OnChange
+// subscribers, in registration order, on the goroutine described there.
+// Close the returned Subscription to stop watching. Keys passed to views are
+// relative to the view prefix.
+func (c *Config) Watch(key string, fn func(old, new interface{})) *Subscription {
+	key = c.key(key)
+	return c.base().subscribe(func(_ ChangeEvent, prev, next *Config) {
+		oldVal, newVal := lookup(prev.v, key), lookup(next.v, key)
+		if !reflect.DeepEqual(oldVal, newVal) {
+			fn(oldVal, newVal)
+		}
+	})
+}
+
+// WatchKey is the typed form of Watch. The old and new values are decoded
+// into T; a value that is unset or cannot be decoded is passed as the zero
+// value of T. fn is only called if the decoded values differ.
+func WatchKey[T any](c *Config, key string, fn func(old, new T)) *Subscription {
+	return c.Watch(key, func(oldRaw, newRaw interface{}) {
+		var oldVal, newVal T
+		if oldRaw != nil && decode(oldRaw, &oldVal) != nil {
+			oldVal = *new(T)
+		}
+		if newRaw != nil && decode(newRaw, &newVal) != nil {
+			newVal = *new(T)
+		}
+		if !reflect.DeepEqual(oldVal, newVal) {
+			fn(oldVal, newVal)
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWatch tests key-scoped change notifications.
+func TestWatch(t *testing.T) {
+	cfg := newTestConfig(t, `
+database:
+  pool:
+    size: 10
+  host: db.local
+`)
+	type change struct{ old, new interface{} }
+	var pool, size []change
+	cfg.Watch("database.pool", func(old, new interface{}) {
+		pool = append(pool, change{old, new})
+	})
+	sub := cfg.Sub("database").Watch("pool.size", func(old, new interface{}) {
+		size = append(size, change{old, new})
+	})
+
+	assert.NoError(t, cfg.Set("database.host", "other"))
+	assert.NoError(t, cfg.Set("database.pool.size", 10))
+	assert.Empty(t, pool)
+	assert.Empty(t, size)
+
+	assert.NoError(t, cfg.Set("database.pool.size", 20))
+	assert.Equal(t, []change{{map[string]interface{}{"size": 10}, map[string]interface{}{"size": 20}}}, pool)
+	assert.Equal(t, []change{{10, 20}}, size)
+
+	assert.NoError(t, sub.Close())
+	assert.NoError(t, cfg.Set("database.pool.max", 5))
+	assert.Len(t, pool, 2)
+	assert.Len(t, size, 1)
+}
+
+// TestWatchUnsetKey tests watching a key that appears and disappears.
+func TestWatchUnsetKey(t *testing.T) {
+	cfg, err := New()
+	assert.NoError(t, err)
+
+	var changes [][2]interface{}
+	cfg.Watch("feature.enabled", func(old, new interface{}) {
+		changes = append(changes, [2]interface{}{old, new})
+	})
+	assert.NoError(t, cfg.Set("feature.enabled", true))
+	assert.NoError(t, cfg.Unset("feature.enabled"))
+	assert.Equal(t, [][2]interface{}{{nil, true}, {true, nil}}, changes)
+}
+
+// TestWatchKey tests typed watches.
+func TestWatchKey(t *testing.T) {
+	cfg := newTestConfig(t, `
+app:
+  port: 8080
+`)
+	var ports [][2]int
+	var order []string
+	WatchKey(cfg, "app.port", func(old, new int) {
+		ports = append(ports, [2]int{old, new})
+		order = append(order, "port")
+	})
+	cfg.OnChange(func(ChangeEvent) {
+		order = append(order, "any")
+	})
+
+	// "8080" decodes to the same int, so only OnChange fires
+	assert.NoError(t, cfg.Set("app.port", "8080"))
+	assert.Empty(t, ports)
+
+	assert.NoError(t, cfg.Set("app.port", "9090"))
+	assert.Equal(t, [][2]int{{8080, 9090}}, ports)
+	assert.Equal(t, []string{"any", "port", "any"}, order)
+}